@@ -68,6 +68,17 @@ func Lookup(svc string) string {
 	return addr
 }
 
+// Exists reports whether an address of svc exists for the env parameter passed to `e2r` in the
+// AddressBook registered with [Set]. Unlike Lookup it never exits, which makes it possible to check
+// for optional addresses before looking them up.
+func Exists(svc string) bool {
+	if len(os.Args) < 2 {
+		return false
+	}
+	_, ok := addrs[os.Args[1]][svc]
+	return ok
+}
+
 // EnvLookup makes it possible to look up addresses durung runtime if an AddressBook has been
 // registered with [Set] at setup. EnvLookup works the same as Lookup but with the environment part
 // being hard coded and overriding any env parameter passed to `e2r`.
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheExpiry is how long a cached runner is kept without being used.
+const cacheExpiry = 7 * 24 * time.Hour
+
+// digest hashes the sources of the packages matched by patterns and all their dependencies.
+// Dependencies downloaded to the module cache are immutable and hashed by module path and version
+// only, while the files of all other packages, eg. of the main module or replaced modules, are
+// hashed by content, including non-Go files and files embedded with //go:embed. The standard
+// library is left out.
+func digest(wd string, patterns ...string) (string, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule | packages.NeedEmbedFiles,
+		Dir:  wd,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return "", fmt.Errorf("loading packages: %v", err)
+	}
+
+	h := sha256.New()
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if err != nil || pkg.Module == nil {
+			return
+		}
+		fmt.Fprintln(h, pkg.PkgPath)
+		if mod := pkg.Module; !mod.Main && mod.Replace == nil && mod.Version != "" {
+			fmt.Fprintln(h, mod.Path, mod.Version)
+			return
+		}
+		for _, name := range slices.Concat(pkg.GoFiles, pkg.OtherFiles, pkg.EmbedFiles) {
+			if err = hashFile(h, name); err != nil {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fmt.Fprintln(w, name)
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// cacheKey identifies a runner built from the current template for the given tests and sources.
+func cacheKey(setup setup, packages []packageInfo, sources string) string {
+	h := sha256.New()
+	fmt.Fprintln(h, runner)
+	fmt.Fprintf(h, "%+v\n%+v\n%s\n", setup, packages, sources)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheDir returns the directory in which runners are cached, creating it if needed. Runners not
+// used for a while are removed.
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "e2r")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > cacheExpiry {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return dir, nil
+}
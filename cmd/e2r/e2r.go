@@ -1,15 +1,22 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 )
 
-const usageInstructions = `Usage: e2r <pattern> [env]
+const usageInstructions = `Usage: e2r [flags] <pattern> [env]
+       e2r gen openapi [flags] <spec>
+       e2r import har|postman [flags] <file>
 
 <pattern> follows the same rules as go test:
   .            current package
@@ -20,11 +27,42 @@ const usageInstructions = `Usage: e2r <pattern> [env]
 [env] is optional:
   Specify an environment name (e.g. DEV, PROD) to pass to your tests.
 
+Flags:
+  --run <regexp> Only run tests matching regexp, matched against SuiteName/testName and SequenceName.
+  --tags <tags>  Only run tests tagged with at least one of the comma separated tags.
+  --skip-tags <tags>
+                 Skip tests tagged with any of the comma separated tags.
+  --timeout <d>  Default timeout of every request, eg. 10s. Overridden by Suites, Sequences and requests.
+  --parallel <n> Cap the number of requests in flight to n.
+  --rate <host=rps>
+                 Limit requests to host to rps requests per second. Repeatable. Host * applies to any host.
+  --openapi <file>
+                 Validate every request and response against an OpenAPI 3 spec, failing tests on violations.
+  --openapi-warn Report violations of the --openapi spec as warnings instead of failing tests.
+  --load         Run a load test, running the tests repeatedly with many users, instead of once.
+  --users <n>    Number of virtual users of a load test (default 10).
+  --duration <d> Duration of a load test, eg. 2m (default 1m).
+  --no-cache     Always rebuild the runner instead of reusing a cached one.
+  --watch        Rerun tests whenever a Go file in the project changes.
+  --ci           Never prompt. Enabled automatically when stdin is not a terminal.
+  --logs <which> Which test logs to print after the run: all, failed or none.
+  --junit <file> Write a JUnit XML report to file.
+  --html <file>  Write a self-contained HTML report to file.
+  --format <fmt> Output format: text (default) or ndjson, streaming one JSON event per line.
+  --out <file>   Write the output of --format to file instead of stdout. Text is still printed.
+
 Examples:
   e2r .                # Run tests in current package
   e2r ./tests          # Run tests in ./tests
   e2r ./tests.go       # Run tests only in tests.go
-  e2r ./... DEV        # Run tests recursively, passing env=DEV`
+  e2r ./... DEV        # Run tests recursively, passing env=DEV
+  e2r --ci ./... DEV   # Run tests in a pipeline
+  e2r --run 'users/' . # Run only tests in Suites matching "users"
+  e2r --tags smoke --skip-tags destructive ./... DEV
+  e2r --load --users 50 --duration 2m ./flows DEV
+  e2r gen openapi spec.yaml --out ./generated
+  e2r import har session.har --out ./flows/session.go
+  e2r import postman collection.json --env dev.json --env prod.json`
 
 const (
 	errorExit   = 1
@@ -32,8 +70,8 @@ const (
 )
 
 const (
-	patternArg = 1
-	envArg     = 2
+	patternArg = 0
+	envArg     = 1
 )
 
 type data struct {
@@ -42,55 +80,314 @@ type data struct {
 	Packages []packageInfo
 }
 
+type options struct {
+	run      string
+	tags     string
+	skipTags string
+	timeout  time.Duration
+	parallel int
+	rates    rates
+	openapi  string
+	warn     bool
+	load     bool
+	users    int
+	duration time.Duration
+	ci       bool
+	logs     string
+	junit    string
+	html     string
+	format   string
+	out      string
+	watch    bool
+	noCache  bool
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		opts, err := parseGenArgs(os.Args[2:])
+		if err != nil {
+			fmt.Printf("%v\n\n%s\n", err, genUsageInstructions)
+			os.Exit(badArgument)
+		}
+		if err := gen(opts); err != nil {
+			fmt.Printf("Error %v\n", err)
+			os.Exit(errorExit)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		opts, err := parseImportArgs(os.Args[2:])
+		if err != nil {
+			fmt.Printf("%v\n\n%s\n", err, importUsageInstructions)
+			os.Exit(badArgument)
+		}
+		if err := importFile(opts); err != nil {
+			fmt.Printf("Error %v\n", err)
+			os.Exit(errorExit)
+		}
+		return
+	}
+
 	wd, _ := os.Getwd()
+	opts, args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Printf("%v\n\n%s\n", err, usageInstructions)
+		os.Exit(badArgument)
+	}
 	var pattern string
 	var env string
-	switch len(os.Args) {
-	case 3:
-		env = os.Args[envArg]
-		fallthrough
+	switch len(args) {
 	case 2:
-		pattern = os.Args[patternArg]
+		env = args[envArg]
+		fallthrough
+	case 1:
+		pattern = args[patternArg]
 	default:
 		fmt.Println(usageInstructions)
 		os.Exit(badArgument)
 	}
 
-	setup, packages, err := load(wd, pattern)
+	if opts.watch {
+		watch(wd, pattern, env, opts)
+	}
+
+	code, err := run(wd, pattern, env, opts.noCache, opts.runnerArgs())
 	if err != nil {
-		fmt.Printf("Error setting up runner: %v\n", err)
+		fmt.Printf("Error %v\n", err)
 		os.Exit(errorExit)
 	}
+	os.Exit(code)
+}
+
+// run generates and builds a runner for the tests matched by pattern, unless an identical runner
+// is already cached, and executes it. It returns the exit code of the runner.
+func run(wd, pattern, env string, noCache bool, runnerArgs []string) (int, error) {
+	setup, packages, sources, err := load(wd, pattern)
+	if err != nil {
+		return 0, fmt.Errorf("setting up runner: %v", err)
+	}
+
+	bin := ""
+	if !noCache {
+		if dir, err := cacheDir(); err == nil {
+			bin = filepath.Join(dir, cacheKey(setup, packages, sources))
+		}
+	}
+	if _, err := os.Stat(bin); bin == "" || err != nil {
+		temporary := bin == ""
+		if bin, err = build(setup, packages, bin); err != nil {
+			return 0, err
+		}
+		if temporary {
+			defer os.RemoveAll(filepath.Dir(bin))
+		}
+	}
+	now := time.Now()
+	os.Chtimes(bin, now, now) // Keeps the runner from expiring
+
+	cmd := exec.Command(bin, append([]string{env}, runnerArgs...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	err = cmd.Run()
+	if exitErr := (&exec.ExitError{}); errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("executing runner: %v", err)
+	}
+	return 0, nil
+}
+
+// build generates and builds a runner to bin and returns its path. If bin is empty the runner is
+// built into a new temporary directory.
+func build(setup setup, packages []packageInfo, bin string) (string, error) {
 	data := data{time.Now().Unix(), setup, packages}
 	dir, err := os.MkdirTemp("", "e2e-runner-*")
 	if err != nil {
-		fmt.Printf("Error setting up runner: %v\n", err)
-		os.Exit(errorExit)
+		return "", fmt.Errorf("setting up runner: %v", err)
+	}
+	if bin != "" {
+		defer os.RemoveAll(dir)
+	} else {
+		bin = filepath.Join(dir, "runner")
 	}
-	defer os.RemoveAll(dir)
 
 	path := filepath.Join(dir, "runner.go")
 	file, err := os.Create(path)
 	if err != nil {
-		fmt.Printf("Error setting up runner: %v\n", err)
-		os.Exit(errorExit)
+		return "", fmt.Errorf("setting up runner: %v", err)
 	}
 	defer file.Close()
 
 	err = template.Must(template.New("runner").Parse(runner)).Execute(file, data)
 	if err != nil {
-		fmt.Printf("Error setting up runner: %v\n", err)
-		os.Exit(errorExit)
+		return "", fmt.Errorf("setting up runner: %v", err)
 	}
 
-	cmd := exec.Command("go", "run", path, env)
+	// The runner is built rather than run with "go run" to be able to reuse it and to get hold of
+	// its exit code. It's built next to the runner source and then moved into place so that a
+	// failed build never leaves a broken runner behind.
+	tmp := filepath.Join(dir, "runner.bin")
+	cmd := exec.Command("go", "build", "-o", tmp, path)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	err = cmd.Run()
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("building runner: %v", err)
+	}
+	if err := os.Rename(tmp, bin); err != nil {
+		return "", fmt.Errorf("building runner: %v", err)
+	}
+	return bin, nil
+}
+
+// parseArgs parses flags and positional arguments. Flags are allowed both before and after
+// positional arguments.
+func parseArgs(args []string) (options, []string, error) {
+	opts := options{}
+	fs := flag.NewFlagSet("e2r", flag.ContinueOnError)
+	fs.Usage = func() {}
+	fs.StringVar(&opts.run, "run", "", "")
+	fs.StringVar(&opts.tags, "tags", "", "")
+	fs.StringVar(&opts.skipTags, "skip-tags", "", "")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "")
+	fs.IntVar(&opts.parallel, "parallel", 0, "")
+	fs.Var(&opts.rates, "rate", "")
+	fs.StringVar(&opts.openapi, "openapi", "", "")
+	fs.BoolVar(&opts.warn, "openapi-warn", false, "")
+	fs.BoolVar(&opts.load, "load", false, "")
+	fs.IntVar(&opts.users, "users", 10, "")
+	fs.DurationVar(&opts.duration, "duration", time.Minute, "")
+	fs.BoolVar(&opts.ci, "ci", false, "")
+	fs.StringVar(&opts.logs, "logs", "", "")
+	fs.StringVar(&opts.junit, "junit", "", "")
+	fs.StringVar(&opts.html, "html", "", "")
+	fs.StringVar(&opts.format, "format", "text", "")
+	fs.StringVar(&opts.out, "out", "", "")
+	fs.BoolVar(&opts.watch, "watch", false, "")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "")
+
+	positional, err := parseFlags(fs, args)
 	if err != nil {
-		fmt.Printf("Error executing runner: %v\n", err)
-		os.Exit(errorExit)
+		return options{}, nil, err
+	}
+
+	if _, err := regexp.Compile(opts.run); err != nil {
+		return options{}, nil, fmt.Errorf("invalid value %q for flag -run: %v", opts.run, err)
+	}
+	switch opts.logs {
+	case "", "all", "failed", "none":
+	default:
+		return options{}, nil, fmt.Errorf("invalid value %q for flag -logs", opts.logs)
+	}
+	if opts.warn && opts.openapi == "" {
+		return options{}, nil, errors.New("flag -openapi-warn requires flag -openapi")
+	}
+	if opts.load && opts.users < 1 {
+		return options{}, nil, fmt.Errorf("invalid value %d for flag -users", opts.users)
+	}
+	if opts.load && opts.watch {
+		return options{}, nil, errors.New("flag -load can't be combined with -watch")
+	}
+	if opts.load && (opts.junit != "" || opts.html != "" || opts.format != "text") {
+		return options{}, nil, errors.New("flag -load can't be combined with -junit, -html or -format, since load tests produce no reports")
+	}
+	switch opts.format {
+	case "text":
+		if opts.out != "" {
+			return options{}, nil, errors.New("flag -out requires a -format other than text")
+		}
+	case "ndjson":
+	default:
+		return options{}, nil, fmt.Errorf("invalid value %q for flag -format", opts.format)
+	}
+
+	return opts, positional, nil
+}
+
+// parseFlags parses args with fs and returns the positional arguments. Flags are allowed both before
+// and after positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// runnerArgs returns the flags understood by the generated runner.
+func (o options) runnerArgs() []string {
+	args := []string{}
+	if o.run != "" {
+		args = append(args, "-run="+o.run)
+	}
+	if o.tags != "" {
+		args = append(args, "-tags="+o.tags)
+	}
+	if o.skipTags != "" {
+		args = append(args, "-skip-tags="+o.skipTags)
+	}
+	if o.timeout != 0 {
+		args = append(args, "-timeout="+o.timeout.String())
+	}
+	if o.parallel > 0 {
+		args = append(args, "-parallel="+strconv.Itoa(o.parallel))
+	}
+	if len(o.rates) > 0 {
+		args = append(args, "-rate="+o.rates.String())
+	}
+	if o.openapi != "" {
+		args = append(args, "-openapi="+o.openapi)
+	}
+	if o.warn {
+		args = append(args, "-openapi-warn")
+	}
+	if o.load {
+		args = append(args, "-users="+strconv.Itoa(o.users), "-duration="+o.duration.String())
+	}
+	if o.ci {
+		args = append(args, "-ci")
+	}
+	if o.logs != "" {
+		args = append(args, "-logs="+o.logs)
+	}
+	if o.junit != "" {
+		args = append(args, "-junit="+o.junit)
+	}
+	if o.html != "" {
+		args = append(args, "-html="+o.html)
+	}
+	if o.format == "ndjson" {
+		out := o.out
+		if out == "" {
+			out = "-"
+		}
+		args = append(args, "-ndjson="+out)
+	}
+	return args
+}
+
+// rates is a repeatable flag of per host rate limits in the format host=rps.
+type rates []string
+
+func (r *rates) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *rates) Set(value string) error {
+	host, rps, ok := strings.Cut(value, "=")
+	if !ok || host == "" {
+		return fmt.Errorf("want host=rps, got %q", value)
+	}
+	if n, err := strconv.ParseFloat(rps, 64); err != nil || n <= 0 {
+		return fmt.Errorf("want a positive number of requests per second, got %q", rps)
 	}
+	*r = append(*r, value)
+	return nil
 }
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const genUsageInstructions = `Usage: e2r gen openapi [flags] <spec>
+
+Generates Go files with one exported e2e.Suite per tag of the OpenAPI 3 spec, and one test per
+operation with an example request and the expected status. URLs are looked up in the AddressBook
+using addr.Lookup, the address of the service being a server URL of the spec.
+
+Flags:
+  --out <dir>    Directory to write the files to (default ./generated). Its name is the package name.
+  --service <s>  Name of the service in the AddressBook (default derived from the title of the spec).`
+
+type genSuite struct {
+	Spec    string
+	Package string
+	Service string
+	Servers []string
+	Var     string
+	Name    string
+	Tests   []genTest
+}
+
+type genTest struct {
+	Name    string
+	Service string
+	Method  string
+	Path    string
+	Headers []genHeader
+	Content string
+	Body    string
+	Status  int
+	Capture []string
+	Notes   []string // Left as comments for things that need attention
+}
+
+type genHeader struct {
+	Key string
+	Val string
+}
+
+type genOptions struct {
+	spec    string
+	out     string
+	service string
+}
+
+// parseGenArgs parses the arguments following "gen".
+func parseGenArgs(args []string) (genOptions, error) {
+	opts := genOptions{}
+	fs := flag.NewFlagSet("e2r gen", flag.ContinueOnError)
+	fs.Usage = func() {}
+	fs.StringVar(&opts.out, "out", "./generated", "")
+	fs.StringVar(&opts.service, "service", "", "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return genOptions{}, err
+	}
+	if len(positional) != 2 || positional[0] != "openapi" {
+		return genOptions{}, errors.New("want: e2r gen openapi <spec>")
+	}
+	opts.spec = positional[1]
+	return opts, nil
+}
+
+// gen generates Go files with one Suite per tag of the spec, testing every operation.
+func gen(opts genOptions) error {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(opts.spec)
+	if err != nil {
+		return fmt.Errorf("loading spec: %v", err)
+	}
+	if opts.service == "" {
+		opts.service = strings.Join(words(doc.Info.Title), "-")
+	}
+	if err := os.MkdirAll(opts.out, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %v", err)
+	}
+	pkg := packageName(opts.out)
+	servers := []string{}
+	for _, server := range doc.Servers {
+		servers = append(servers, server.URL)
+	}
+
+	suites := map[string]*genSuite{}
+	for _, path := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(path)
+		for _, method := range slices.Sorted(maps.Keys(item.Operations())) {
+			op := item.GetOperation(method)
+			tag := "default"
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			if suites[tag] == nil {
+				suites[tag] = &genSuite{Spec: filepath.Base(opts.spec), Package: pkg, Service: opts.service, Servers: servers, Name: tag}
+			}
+			suites[tag].Tests = append(suites[tag].Tests, genOperation(opts.service, path, method, item, op))
+		}
+	}
+	if len(suites) == 0 {
+		return errors.New("spec contains no operations")
+	}
+
+	vars, files := map[string]bool{}, map[string]bool{}
+	for _, tag := range slices.Sorted(maps.Keys(suites)) {
+		suite := suites[tag]
+		suite.Var = identifier(tag, vars)
+		slices.SortFunc(suite.Tests, func(a, b genTest) int { return strings.Compare(a.Name, b.Name) })
+
+		file := filepath.Join(opts.out, fileName(tag, files))
+		if err := generate(file, suiteTemplate, suite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genOperation returns a test of the operation method path of service.
+func genOperation(service, path, method string, item *openapi3.PathItem, op *openapi3.Operation) genTest {
+	test := genTest{Name: op.OperationID, Service: service, Method: method}
+	if test.Name == "" {
+		test.Name = method + " " + path
+	}
+
+	query := url.Values{}
+	for _, ref := range slices.Concat(item.Parameters, op.Parameters) {
+		param := ref.Value
+		if param == nil {
+			continue
+		}
+		val := paramExample(param)
+		switch param.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(val))
+		case openapi3.ParameterInQuery:
+			if param.Required {
+				query.Add(param.Name, val)
+			}
+		case openapi3.ParameterInHeader:
+			if param.Required {
+				test.Headers = append(test.Headers, genHeader{param.Name, val})
+			}
+		}
+	}
+	test.Path = path
+	if len(query) > 0 {
+		test.Path += "?" + query.Encode()
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		content := op.RequestBody.Value.Content
+		for _, mime := range slices.Sorted(maps.Keys(content)) {
+			if strings.Contains(mime, "json") {
+				test.Content = mime
+				body, _ := json.MarshalIndent(mediaExample(content[mime]), "", "\t")
+				test.Body = string(body)
+				break
+			}
+		}
+	}
+
+	if op.Responses != nil {
+		for _, code := range slices.Sorted(maps.Keys(op.Responses.Map())) {
+			if status, err := strconv.Atoi(code); err == nil {
+				if test.Status == 0 || (status >= 200 && status < 300 && (test.Status < 200 || test.Status >= 300)) {
+					test.Status = status
+				}
+			}
+		}
+	}
+	return test
+}
+
+func paramExample(param *openapi3.Parameter) string {
+	if param.Example != nil {
+		return fmt.Sprint(param.Example)
+	}
+	for _, name := range slices.Sorted(maps.Keys(param.Examples)) {
+		if ex := param.Examples[name]; ex.Value != nil && ex.Value.Value != nil {
+			return fmt.Sprint(ex.Value.Value)
+		}
+	}
+	if param.Schema != nil {
+		if val, ok := sample(param.Schema, 0).(string); ok {
+			return val
+		}
+		return fmt.Sprint(sample(param.Schema, 0))
+	}
+	return param.Name
+}
+
+func mediaExample(media *openapi3.MediaType) any {
+	if media.Example != nil {
+		return media.Example
+	}
+	for _, name := range slices.Sorted(maps.Keys(media.Examples)) {
+		if ex := media.Examples[name]; ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value
+		}
+	}
+	return sample(media.Schema, 0)
+}
+
+// sample returns an example value of the schema, made up from its type if it has no example.
+func sample(ref *openapi3.SchemaRef, depth int) any {
+	if ref == nil || ref.Value == nil || depth > 5 {
+		return nil
+	}
+	schema := ref.Value
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := map[string]any{}
+		for _, part := range schema.AllOf {
+			if obj, ok := sample(part, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return sample(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return sample(schema.AnyOf[0], depth+1)
+	}
+
+	switch {
+	case schema.Type.Is(openapi3.TypeObject) || len(schema.Properties) > 0:
+		obj := map[string]any{}
+		for name, prop := range schema.Properties {
+			obj[name] = sample(prop, depth+1)
+		}
+		return obj
+	case schema.Type.Is(openapi3.TypeArray):
+		return []any{sample(schema.Items, depth+1)}
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		return 1
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return true
+	case schema.Format == "date-time":
+		return "2024-01-01T00:00:00Z"
+	case schema.Format == "date":
+		return "2024-01-01"
+	case schema.Format == "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	default:
+		return "string"
+	}
+}
+
+// generate executes the template text with data and writes the formatted result to file.
+func generate(file, text string, data any) error {
+	tmpl := template.Must(template.New("file").Funcs(template.FuncMap{"quote": quote}).Parse(text))
+	template.Must(tmpl.Parse(testTemplate))
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return fmt.Errorf("generating %s: %v", file, err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting %s: %v", file, err)
+	}
+	if err := os.WriteFile(file, src, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %v", file, err)
+	}
+	fmt.Println("Generated", file)
+	return nil
+}
+
+// packageName returns the name of the package in dir.
+func packageName(dir string) string {
+	abs, _ := filepath.Abs(dir)
+	pkg := strings.Join(words(filepath.Base(abs)), "")
+	if pkg == "" || !unicode.IsLetter(rune(pkg[0])) {
+		return "generated"
+	}
+	return pkg
+}
+
+// words splits s into lowercase words of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// identifier returns an exported Go identifier made from s, unique among taken.
+func identifier(s string, taken map[string]bool) string {
+	id := ""
+	for _, word := range words(s) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	if id == "" || !unicode.IsLetter(rune(id[0])) {
+		id = "Suite" + id
+	}
+	unique := id
+	for i := 2; taken[unique]; i++ {
+		unique = id + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// fileName returns the name of a Go file made from s, unique among taken.
+func fileName(s string, taken map[string]bool) string {
+	name := strings.Join(words(s), "_")
+	if name == "" {
+		name = "suite"
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique + ".go"
+}
+
+// quote returns s as a Go string literal, preferring a raw string.
+func quote(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+var suiteTemplate = `// Code generated by e2r gen openapi from {{ .Spec }}.
+// It's meant as a starting point and can be edited freely.
+
+package {{ .Package }}
+
+import (
+	"github.com/gombrii/go-e2e"
+	"github.com/gombrii/go-e2e/addr"
+)
+
+// {{ .Var }} tests the operations tagged {{ quote .Name }}.
+{{- with .Servers }}
+//
+// The address of {{ quote $.Service }} in the AddressBook is one of the servers of the spec:
+{{- range . }}
+//   - {{ . }}
+{{- end }}
+{{- end }}
+var {{ .Var }} = e2e.Suite{
+	Name: {{ quote .Name }},
+	Tests: e2e.Tests{
+{{- range .Tests }}
+		{{ quote .Name }}: {{ template "test" . }},
+{{- end }}
+	},
+}
+`
+
+// testTemplate renders a genTest as an e2e.Test literal.
+var testTemplate = `{{ define "test" -}}
+{
+{{- range .Notes }}
+	// TODO: {{ . }}
+{{- end }}
+	Request: e2e.Request{
+		Method: {{ quote .Method }},
+		URL: addr.Lookup({{ quote .Service }}) + {{ quote .Path }},
+{{- with .Headers }}
+		Headers: e2e.Headers{
+{{- range . }}
+			{Key: {{ quote .Key }}, Val: {{ quote .Val }}},
+{{- end }}
+		},
+{{- end }}
+{{- with .Content }}
+		Content: {{ quote . }},
+{{- end }}
+{{- with .Body }}
+		Body: {{ quote . }},
+{{- end }}
+	},
+{{- if .Status }}
+	Expect: e2e.Expect{
+		Status: {{ .Status }},
+	},
+{{- end }}
+{{- with .Capture }}
+	Capture: e2e.Captors{ {{- range $i, $c := . }}{{ if $i }}, {{ end }}{{ quote $c }}{{ end -}} },
+{{- end }}
+}
+{{- end }}`
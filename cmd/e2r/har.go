@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// har is the part of an HTTP Archive used when importing it.
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string
+				URL      string
+				Headers  []harHeader
+				PostData *struct {
+					MimeType string
+					Text     string
+				}
+			}
+			Response struct {
+				Status  int
+				Content struct {
+					MimeType string
+					Text     string
+					Encoding string
+				}
+			}
+		}
+	}
+}
+
+type harHeader struct {
+	Name  string
+	Value string
+}
+
+type harSequence struct {
+	Source   string
+	Package  string
+	Var      string
+	Name     string
+	Services map[string]string
+	Steps    []genTest
+}
+
+// ignoredHeaders are request headers set by browsers and HTTP clients themselves.
+var ignoredHeaders = []string{
+	"accept-encoding", "accept-language", "cache-control", "connection", "content-length",
+	"content-type", "dnt", "host", "origin", "pragma", "priority", "referer", "te",
+	"upgrade-insecure-requests", "user-agent",
+}
+
+// staticContent matches the MIME types of page resources rather than API calls.
+var staticContent = regexp.MustCompile(`^(image|font|audio|video)/|css|javascript|html`)
+
+// captureName matches the names of captors that can be referenced as variables.
+var captureName = regexp.MustCompile(`^\w+$`)
+
+// importHAR generates a Go file with a Sequence making the requests recorded in a HAR file.
+func importHAR(opts importOptions) error {
+	content, err := os.ReadFile(opts.file)
+	if err != nil {
+		return fmt.Errorf("reading HAR: %v", err)
+	}
+	var archive har
+	if err := json.Unmarshal(content, &archive); err != nil {
+		return fmt.Errorf("parsing HAR: %v", err)
+	}
+
+	seq := harSequence{
+		Source:   filepath.Base(opts.file),
+		Package:  packageName(filepath.Dir(opts.out)),
+		Var:      identifier(opts.name, map[string]bool{}),
+		Name:     opts.name,
+		Services: map[string]string{},
+	}
+	dynamic := dynamicValues{byValue: map[string]*captor{}, byName: map[string]*captor{}}
+	sent := ""
+	for _, entry := range archive.Log.Entries {
+		if !opts.all && staticContent.MatchString(entry.Response.Content.MimeType) {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return fmt.Errorf("parsing HAR: %v", err)
+		}
+		step := genTest{Service: u.Host, Method: entry.Request.Method, Path: u.RequestURI(), Status: entry.Response.Status}
+		seq.Services[u.Host] = u.Scheme + "://" + u.Host
+		for _, h := range entry.Request.Headers {
+			if strings.HasPrefix(h.Name, ":") || strings.HasPrefix(strings.ToLower(h.Name), "sec-") || slices.Contains(ignoredHeaders, strings.ToLower(h.Name)) {
+				continue
+			}
+			step.Headers = append(step.Headers, genHeader{h.Name, h.Value})
+		}
+		if entry.Request.PostData != nil {
+			step.Content = entry.Request.PostData.MimeType
+			step.Body = entry.Request.PostData.Text
+		}
+		sent += " " + entry.Request.URL + " " + step.Body
+		for _, h := range step.Headers {
+			sent += " " + h.Val
+		}
+
+		step.Path = dynamic.reference(step.Path)
+		for i, h := range step.Headers {
+			step.Headers[i].Val = dynamic.reference(h.Val)
+		}
+		step.Body = dynamic.reference(step.Body)
+
+		seq.Steps = append(seq.Steps, step)
+		body := entry.Response.Content.Text
+		if entry.Response.Content.Encoding == "base64" {
+			decoded, _ := base64.StdEncoding.DecodeString(body)
+			body = string(decoded)
+		}
+		dynamic.candidates(len(seq.Steps)-1, body, sent)
+	}
+	if len(seq.Steps) == 0 {
+		return fmt.Errorf("no requests to import in %s", opts.file)
+	}
+
+	for _, c := range dynamic.captors {
+		if c.used {
+			seq.Steps[c.step].Capture = append(seq.Steps[c.step].Capture, c.name)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.out), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %v", err)
+	}
+	return generate(opts.out, sequenceTemplate, seq)
+}
+
+// dynamicValues keeps track of values in response bodies that are sent in later requests, such
+// as tokens and ids, replacing them with references to captured variables.
+type dynamicValues struct {
+	captors []*captor
+	byValue map[string]*captor
+	byName  map[string]*captor // The captor currently holding the value of each variable
+}
+
+type captor struct {
+	name  string
+	value string
+	step  int // Index of the step capturing the value
+	used  bool
+}
+
+// candidates registers the top level fields of the JSON body of the response to step as possible
+// captors, except values that were already sent, since they aren't made up by the server.
+func (d *dynamicValues) candidates(step int, body, sent string) {
+	var fields map[string]any
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber() // Keeps numbers as sent, eg. 1234567 rather than 1.234567e+06
+	if decoder.Decode(&fields) != nil {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		var value string
+		switch v := fields[name].(type) {
+		case string:
+			value = v
+		case json.Number:
+			// Captured numbers are formatted as floats, so numbers that would be captured in
+			// another form, eg. 1.234567e+06, can't be referenced
+			if f, err := v.Float64(); err != nil || fmt.Sprint(f) != v.String() {
+				continue
+			}
+			value = v.String()
+		default:
+			continue
+		}
+		if len(value) < 3 || !captureName.MatchString(name) || occurs(sent, value) {
+			continue
+		}
+		if prev, ok := d.byName[name]; ok { // Capturing name again replaces the previous value
+			delete(d.byValue, prev.value)
+		}
+		c := &captor{name: name, value: value, step: step}
+		d.captors = append(d.captors, c)
+		d.byValue[value] = c
+		d.byName[name] = c
+	}
+}
+
+// reference replaces the values of captors in s with references to their variables. Longer
+// values are replaced first in case one value contains another.
+func (d *dynamicValues) reference(s string) string {
+	values := slices.SortedFunc(maps.Keys(d.byValue), func(a, b string) int { return len(b) - len(a) })
+	for _, value := range values {
+		c := d.byValue[value]
+		if replaced := replaceWord(s, value, "$"+c.name); replaced != s {
+			s = replaced
+			c.used = true
+		}
+	}
+	return s
+}
+
+// occurs reports whether value occurs in s as a whole word.
+func occurs(s, value string) bool {
+	return replaceWord(s, value, "") != s
+}
+
+// replaceWord replaces the occurrences of value in s that aren't part of a longer word with repl.
+func replaceWord(s, value, repl string) string {
+	word := func(i int) bool {
+		return i >= 0 && i < len(s) && (s[i] == '_' || unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])))
+	}
+	var b strings.Builder
+	last := 0
+	for i := 0; i+len(value) <= len(s); {
+		if s[i:i+len(value)] == value && !word(i-1) && !word(i+len(value)) {
+			b.WriteString(s[last:i])
+			b.WriteString(repl)
+			i += len(value)
+			last = i
+			continue
+		}
+		i++
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+var sequenceTemplate = `// Code generated by e2r import har from {{ .Source }}.
+// It's meant as a starting point and can be edited freely.
+
+package {{ .Package }}
+
+import (
+	"github.com/gombrii/go-e2e"
+	"github.com/gombrii/go-e2e/addr"
+)
+
+// {{ .Var }} replays the requests recorded in {{ .Source }}.
+//
+// The addresses of the services in the AddressBook were recorded as:
+{{- range $svc, $addr := .Services }}
+//   - {{ quote $svc }}: {{ $addr }}
+{{- end }}
+var {{ .Var }} = e2e.Sequence{
+	Name: {{ quote .Name }},
+	Steps: e2e.Steps{
+{{- range .Steps }}
+		{{ template "test" . }},
+{{- end }}
+	},
+}
+`
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"strings"
+)
+
+const importUsageInstructions = `Usage: e2r import har [flags] <file>
+       e2r import postman [flags] <file>
+
+Generates Go files from recorded or exported requests.
+
+  har  Converts the requests of a HAR file, eg. a browser session, into an e2e.Sequence. Values
+       in JSON responses that are sent in later requests, such as tokens and ids, are captured and
+       referenced as variables. Requests for images, fonts, stylesheets, scripts and pages are left
+       out. URLs are looked up in the AddressBook using addr.Lookup with the host as service.
+
+  postman
+       Converts a Postman collection into an e2e.Suite per folder, or an e2e.Sequence if requests
+       of the folder capture variables in their test scripts. {{var}} references become $var.
+       URLs starting with a variable, eg. {{baseUrl}}, are looked up in the AddressBook using the
+       name of the variable as service.
+
+Flags:
+  --out <path>   File to write a HAR file to, or directory to write a Postman collection to (default
+                 named after <file>). The name of the directory is the package name.
+  --name <name>  Name of the Sequence of a HAR file (default the name of <file>).
+  --all          Import every request of a HAR file, including page resources.
+  --env <file>   Postman environment to add to a generated AddressBook. Repeatable.`
+
+type importOptions struct {
+	format string
+	file   string
+	out    string
+	name   string
+	all    bool
+	envs   files
+}
+
+// parseImportArgs parses the arguments following "import".
+func parseImportArgs(args []string) (importOptions, error) {
+	opts := importOptions{}
+	fs := flag.NewFlagSet("e2r import", flag.ContinueOnError)
+	fs.Usage = func() {}
+	fs.StringVar(&opts.out, "out", "", "")
+	fs.StringVar(&opts.name, "name", "", "")
+	fs.BoolVar(&opts.all, "all", false, "")
+	fs.Var(&opts.envs, "env", "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return importOptions{}, err
+	}
+	if len(positional) != 2 || (positional[0] != "har" && positional[0] != "postman") {
+		return importOptions{}, errors.New("want: e2r import har|postman <file>")
+	}
+	opts.format, opts.file = positional[0], positional[1]
+	if len(opts.envs) > 0 && opts.format != "postman" {
+		return importOptions{}, errors.New("flag -env requires postman")
+	}
+
+	base := strings.TrimSuffix(filepath.Base(opts.file), filepath.Ext(opts.file))
+	if opts.out == "" {
+		opts.out = strings.Join(words(base), "_")
+		if opts.format == "har" {
+			opts.out += ".go"
+		}
+	}
+	if opts.name == "" {
+		opts.name = base
+	}
+	return opts, nil
+}
+
+// importFile generates a Go file from the file of opts.
+func importFile(opts importOptions) error {
+	if opts.format == "postman" {
+		return importPostman(opts)
+	}
+	return importHAR(opts)
+}
+
+// files is a repeatable flag of file paths.
+type files []string
+
+func (f *files) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -12,6 +12,8 @@ import (
 	"golang.org/x/tools/go/packages"
 )
 
+const e2ePath = "github.com/gombrii/go-e2e"
+
 type setup struct {
 	PkgPath   string
 	PkgName   string
@@ -30,7 +32,12 @@ type exportedVar struct {
 	TypeName string
 }
 
-func load(wd, pattern string) (setup, []packageInfo, error) {
+// load finds the setup hooks and all tests matched by pattern. It also returns a digest of the
+// sources of all loaded packages and their dependencies.
+func load(wd, pattern string) (setup, []packageInfo, string, error) {
+	// Dependencies aren't loaded, their types being read from export data instead of type-checked
+	// from source, which keeps loading fast. Older versions of x/tools than the one in go.mod
+	// can't type packages importing others without NeedImports and NeedDeps.
 	cfg := &packages.Config{
 		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedFiles,
 		Dir:  wd,
@@ -38,15 +45,21 @@ func load(wd, pattern string) (setup, []packageInfo, error) {
 
 	hks, err := loadSetup(cfg)
 	if err != nil {
-		return setup{}, nil, err
+		return setup{}, nil, "", err
 	}
 
 	pkgs, err := loadPackages(cfg, wd, pattern)
 	if err != nil {
-		return setup{}, nil, err
+		return setup{}, nil, "", err
 	}
 
-	return hks, pkgs, nil
+	dir, _ := separate(wd, pattern)
+	sum, err := digest(wd, ".", dir)
+	if err != nil {
+		return setup{}, nil, "", fmt.Errorf("hashing sources: %v", err)
+	}
+
+	return hks, pkgs, sum, nil
 }
 
 func loadPackages(cfg *packages.Config, wd, pattern string) ([]packageInfo, error) {
@@ -60,6 +73,10 @@ func loadPackages(cfg *packages.Config, wd, pattern string) ([]packageInfo, erro
 	containsTests := false
 
 	for _, pkg := range pkgs {
+		set := setInterface(pkg.Types)
+		if set == nil {
+			continue // Can't contain any sets without depending on go-e2e
+		}
 		var exportedVars []exportedVar
 		for _, file := range pkg.Syntax {
 			if targetFile != "" {
@@ -91,23 +108,13 @@ func loadPackages(cfg *packages.Config, wd, pattern string) ([]packageInfo, erro
 						if obj == nil || !obj.Exported() {
 							continue
 						}
-						typ := obj.Type()
-
-						named, ok := typ.(*types.Named)
-						if !ok {
-							continue
-						}
-						if named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "github.com/gombrii/go-e2e" {
-							continue
-						}
-						typeName := named.Obj().Name()
-						if typeName != "Suite" && typeName != "Sequence" {
+						if !types.Implements(obj.Type(), set) {
 							continue
 						}
 
 						exportedVars = append(exportedVars, exportedVar{
 							VarName:  name.Name,
-							TypeName: typeName,
+							TypeName: types.TypeString(obj.Type(), types.RelativeTo(pkg.Types)),
 						})
 						containsTests = true
 					}
@@ -127,6 +134,32 @@ func loadPackages(cfg *packages.Config, wd, pattern string) ([]packageInfo, erro
 	return packages, nil
 }
 
+// setInterface returns the Set interface of go-e2e if pkg depends on it, directly or indirectly.
+func setInterface(pkg *types.Package) *types.Interface {
+	seen := map[*types.Package]bool{}
+	var find func(pkg *types.Package) *types.Interface
+	find = func(pkg *types.Package) *types.Interface {
+		if seen[pkg] {
+			return nil
+		}
+		seen[pkg] = true
+		if pkg.Path() == e2ePath {
+			if obj, ok := pkg.Scope().Lookup("Set").(*types.TypeName); ok {
+				iface, _ := obj.Type().Underlying().(*types.Interface)
+				return iface
+			}
+			return nil
+		}
+		for _, imp := range pkg.Imports() {
+			if iface := find(imp); iface != nil {
+				return iface
+			}
+		}
+		return nil
+	}
+	return find(pkg)
+}
+
 func loadSetup(cfg *packages.Config) (setup, error) {
 	pkgs, err := packages.Load(cfg, ".")
 	if err != nil || len(pkgs) == 0 {
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadImportingAddr(t *testing.T) {
+	wd, err := filepath.Abs(filepath.Join("testdata", "addr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkgs, _, err := load(wd, ".")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(pkgs) != 1 || len(pkgs[0].ExportedVars) != 1 || pkgs[0].ExportedVars[0].VarName != "Users" {
+		t.Fatalf("got %+v, want the package with the Suite Users", pkgs)
+	}
+}
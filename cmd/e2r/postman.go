@@ -0,0 +1,611 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// postmanCollection is the part of a Postman collection, format v2.1, used when importing it.
+type postmanCollection struct {
+	Info struct {
+		Name string
+	}
+	Item     []postmanItem
+	Variable []postmanVariable
+	Auth     *postmanAuth
+}
+
+type (
+	// postmanItem is either a folder of items or a request.
+	postmanItem struct {
+		Name     string
+		Item     []postmanItem
+		Request  *postmanRequest
+		Event    []postmanEvent
+		Response []struct {
+			Code int
+		}
+		Auth *postmanAuth
+	}
+	postmanRequest struct {
+		Method string
+		Header []postmanVariable
+		URL    postmanURL
+		Body   *struct {
+			Mode       string
+			Raw        string
+			URLEncoded []postmanVariable
+			Options    struct {
+				Raw struct {
+					Language string
+				}
+			}
+		}
+		Auth *postmanAuth
+	}
+	postmanURL struct {
+		Raw      string
+		Host     []string
+		Path     []string
+		Query    []postmanVariable
+		Variable []postmanVariable
+	}
+	postmanAuth struct {
+		Type   string
+		Bearer []postmanVariable
+		APIKey []postmanVariable
+	}
+	postmanEvent struct {
+		Listen string
+		Script struct {
+			Exec postmanScript
+		}
+	}
+	// postmanScript is the source of a script, given either as a string or as an array of lines.
+	postmanScript   string
+	postmanVariable struct {
+		Key      string
+		Value    any
+		Disabled bool
+		Enabled  *bool // Used by environments instead of Disabled
+	}
+	postmanEnvironment struct {
+		Name   string
+		Values []postmanVariable
+	}
+)
+
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &u.Raw); err == nil {
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(b, (*plain)(u))
+}
+
+func (s *postmanScript) UnmarshalJSON(b []byte) error {
+	var lines []string
+	if err := json.Unmarshal(b, &lines); err != nil {
+		return json.Unmarshal(b, (*string)(s))
+	}
+	*s = postmanScript(strings.Join(lines, "\n"))
+	return nil
+}
+
+func (v postmanVariable) value() string {
+	if v.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(v.Value)
+}
+
+func (v postmanVariable) off() bool {
+	return v.Disabled || (v.Enabled != nil && !*v.Enabled)
+}
+
+type postmanFile struct {
+	Source   string
+	Package  string
+	Book     bool
+	Services map[string]string
+	Sets     []postmanSet
+}
+
+type postmanSet struct {
+	Var      string
+	Name     string
+	Sequence bool
+	Env      []string
+	Tests    []genTest
+}
+
+type postmanBook struct {
+	Source string
+	Envs   map[string]map[string]string
+}
+
+var (
+	// postmanVar matches references to variables, eg. {{token}}.
+	postmanVar = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	// setVar matches scripts setting a variable, eg. pm.environment.set("token", json.token).
+	setVar = regexp.MustCompile(`pm\.(?:environment|collectionVariables|globals|variables)\.set\(\s*["']([^"']+)["']\s*,\s*([^;\n]+?)\s*\)\s*;?\s*$`)
+	// topLevelField matches expressions reading a top level field of the response body.
+	topLevelField = regexp.MustCompile(`^(?:pm\.response\.json\(\)|\w+)\.(\w+)$`)
+	// expectedStatus matches assertions on the response status.
+	expectedStatus = regexp.MustCompile(`(?:to\.have\.status|response\.code\)\.to\.(?:eql|equal))\(\s*(\d{3})\s*\)`)
+	nonWord        = regexp.MustCompile(`\W`)
+)
+
+// postmanImporter translates the requests of a Postman collection.
+type postmanImporter struct {
+	collection map[string]string   // Values of collection variables
+	env        map[string]bool     // Names of environment variables
+	captured   map[string]captured // Variables set by scripts
+	services   map[string]string   // Addresses of services not in the environments
+	used       []string            // Environment variables used by the set being translated
+	set        string              // Name of the set being translated
+}
+
+// captured is a variable set by a script.
+type captured struct {
+	field string // Top level field of the response body the variable is set to
+	set   string // Name of the set capturing the variable
+}
+
+// importPostman generates Go files with a Suite or Sequence per folder of a Postman collection, and
+// an AddressBook of the Postman environments, if any.
+func importPostman(opts importOptions) error {
+	content, err := os.ReadFile(opts.file)
+	if err != nil {
+		return fmt.Errorf("reading collection: %v", err)
+	}
+	var collection postmanCollection
+	if err := json.Unmarshal(content, &collection); err != nil {
+		return fmt.Errorf("parsing collection: %v", err)
+	}
+
+	imp := postmanImporter{collection: map[string]string{}, env: map[string]bool{}, captured: map[string]captured{}, services: map[string]string{}}
+	for _, v := range collection.Variable {
+		if !v.off() {
+			imp.collection[v.Key] = v.value()
+		}
+	}
+	book := postmanBook{Source: filepath.Base(opts.file), Envs: map[string]map[string]string{}}
+	for _, file := range opts.envs {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading environment: %v", err)
+		}
+		var env postmanEnvironment
+		if err := json.Unmarshal(content, &env); err != nil {
+			return fmt.Errorf("parsing environment %s: %v", file, err)
+		}
+		if env.Name == "" {
+			env.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		book.Envs[env.Name] = map[string]string{}
+		for _, v := range env.Values {
+			if !v.off() {
+				book.Envs[env.Name][sanitize(v.Key)] = v.value()
+				imp.env[v.Key] = true
+			}
+		}
+	}
+
+	out := postmanFile{Source: book.Source, Package: packageName(opts.out), Book: len(book.Envs) > 0, Services: imp.services}
+	name := collection.Info.Name
+	if name == "" {
+		name = strings.TrimSuffix(book.Source, filepath.Ext(book.Source))
+	}
+	imp.folder(&out, name, collection.Item, collection.Auth, map[string]bool{})
+	if len(out.Sets) == 0 {
+		return fmt.Errorf("no requests to import in %s", opts.file)
+	}
+	for _, vars := range book.Envs { // The services are looked up in every environment
+		for svc, addr := range imp.services {
+			if _, ok := vars[svc]; !ok {
+				vars[svc] = addr
+			}
+		}
+	}
+
+	if err := os.MkdirAll(opts.out, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %v", err)
+	}
+	file := filepath.Join(opts.out, strings.Join(words(name), "_")+".go")
+	if err := generate(file, postmanTemplate, out); err != nil {
+		return err
+	}
+	if out.Book {
+		// The helper is shared by all collections imported to the directory. Its name can't be
+		// taken by a collection, since their names contain no hyphens.
+		if err := generate(filepath.Join(opts.out, "postman-environment.go"), environmentTemplate, out); err != nil {
+			return err
+		}
+		// The AddressBook has a package of its own, since it must be registered before the sets
+		// looking up addresses are initialized
+		dir := filepath.Join(opts.out, "addressbook")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating output directory: %v", err)
+		}
+		if err := generate(filepath.Join(dir, "postman-addressbook.go"), sharedBookTemplate, nil); err != nil {
+			return err
+		}
+		// Each collection adds its environments to the AddressBook from a file of its own, so that
+		// collections imported to the same directory don't overwrite each other's
+		return generate(filepath.Join(dir, filepath.Base(file)), addressBookTemplate, book)
+	}
+	return nil
+}
+
+// folder adds a set of the requests of a folder to out, followed by sets of its subfolders.
+func (imp *postmanImporter) folder(out *postmanFile, name string, items []postmanItem, auth *postmanAuth, vars map[string]bool) {
+	set := postmanSet{Name: name}
+	imp.used = nil
+	imp.set = name
+	names := map[string]bool{}
+	for _, item := range items {
+		if item.Request == nil {
+			continue
+		}
+		test := imp.request(item, auth)
+		test.Name = item.Name
+		for i := 2; names[test.Name]; i++ {
+			test.Name = fmt.Sprintf("%s %d", item.Name, i)
+		}
+		names[test.Name] = true
+		set.Sequence = set.Sequence || len(test.Capture) > 0
+		set.Tests = append(set.Tests, test)
+	}
+	if len(set.Tests) > 0 {
+		set.Var = identifier(name, vars)
+		slices.Sort(imp.used)
+		set.Env = slices.Compact(imp.used)
+		out.Sets = append(out.Sets, set)
+	}
+
+	for _, item := range items {
+		if item.Request == nil {
+			folderAuth := auth
+			if item.Auth != nil {
+				folderAuth = item.Auth
+			}
+			imp.folder(out, name+" - "+item.Name, item.Item, folderAuth, vars)
+		}
+	}
+}
+
+// request translates a request inheriting auth.
+func (imp *postmanImporter) request(item postmanItem, auth *postmanAuth) genTest {
+	req := item.Request
+	test := genTest{Method: req.Method}
+	if test.Method == "" {
+		test.Method = "GET"
+	}
+
+	raw := req.URL.Raw
+	if raw == "" {
+		raw = strings.Join(req.URL.Host, ".") + "/" + strings.Join(req.URL.Path, "/")
+		query := []string{}
+		for _, q := range req.URL.Query {
+			if !q.off() {
+				query = append(query, q.Key+"="+q.value())
+			}
+		}
+		if len(query) > 0 {
+			raw += "?" + strings.Join(query, "&")
+		}
+	}
+	for _, v := range req.URL.Variable { // Path variables, eg. /users/:id
+		raw = regexp.MustCompile(`/:`+regexp.QuoteMeta(v.Key)+`\b`).ReplaceAllLiteralString(raw, "/"+v.value())
+	}
+	test.Service, test.Path = imp.address(raw, &test.Notes)
+
+	for _, h := range req.Header {
+		if h.off() {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") {
+			test.Content = imp.convert(h.value(), &test.Notes)
+			continue
+		}
+		test.Headers = append(test.Headers, genHeader{h.Key, imp.convert(h.value(), &test.Notes)})
+	}
+	if req.Auth != nil {
+		auth = req.Auth
+	} else if item.Auth != nil {
+		auth = item.Auth
+	}
+	if auth != nil {
+		test.Headers = append(test.Headers, imp.auth(auth, &test.Notes)...)
+	}
+
+	if req.Body != nil {
+		switch req.Body.Mode {
+		case "raw":
+			test.Body = imp.convert(req.Body.Raw, &test.Notes)
+			if test.Content == "" {
+				switch req.Body.Options.Raw.Language {
+				case "json":
+					test.Content = "application/json"
+				case "xml":
+					test.Content = "application/xml"
+				case "text":
+					test.Content = "text/plain"
+				}
+			}
+		case "urlencoded":
+			pairs := []string{}
+			for _, p := range req.Body.URLEncoded {
+				if !p.off() {
+					pairs = append(pairs, escape(imp.convert(p.Key, &test.Notes))+"="+escape(imp.convert(p.value(), &test.Notes)))
+				}
+			}
+			test.Body = strings.Join(pairs, "&")
+			if test.Content == "" {
+				test.Content = "application/x-www-form-urlencoded"
+			}
+		case "", "none":
+		default:
+			test.Notes = append(test.Notes, fmt.Sprintf("The %s body of the request wasn't imported.", req.Body.Mode))
+		}
+	}
+
+	for _, event := range item.Event {
+		script := string(event.Script.Exec)
+		if strings.TrimSpace(script) == "" {
+			continue
+		}
+		if event.Listen == "prerequest" {
+			test.Notes = append(test.Notes, "The pre-request script of the request wasn't imported.")
+			continue
+		}
+		if m := expectedStatus.FindStringSubmatch(script); m != nil {
+			test.Status, _ = strconv.Atoi(m[1])
+		}
+		for _, line := range strings.Split(script, "\n") {
+			m := setVar.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+			field := topLevelField.FindStringSubmatch(m[2])
+			if field == nil {
+				test.Notes = append(test.Notes, fmt.Sprintf("The variable %q is set by a script and needs to be captured manually.", m[1]))
+				continue
+			}
+			imp.captured[m[1]] = captured{field[1], imp.set}
+			test.Capture = append(test.Capture, field[1])
+		}
+	}
+	if test.Status == 0 && len(item.Response) > 0 {
+		test.Status = item.Response[0].Code
+	}
+	return test
+}
+
+// address splits the URL raw into a service to look up in the AddressBook and the rest of the URL.
+// A URL starting with a variable, eg. {{baseUrl}}/users, is looked up by the name of the variable
+// and other URLs by their host.
+func (imp *postmanImporter) address(raw string, notes *[]string) (string, string) {
+	if loc := postmanVar.FindStringSubmatchIndex(raw); loc != nil && loc[0] == 0 {
+		name := strings.TrimSpace(raw[loc[2]:loc[3]])
+		if val, ok := imp.collection[name]; ok && !imp.env[name] {
+			imp.services[sanitize(name)] = val
+		}
+		return sanitize(name), imp.convert(raw[loc[1]:], notes)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	scheme, rest, _ := strings.Cut(raw, "://")
+	host, path, _ := strings.Cut(rest, "/")
+	host = imp.convert(host, notes)
+	imp.services[host] = scheme + "://" + host
+	return host, imp.convert("/"+path, notes)
+}
+
+// auth returns the headers of auth.
+func (imp *postmanImporter) auth(auth *postmanAuth, notes *[]string) []genHeader {
+	get := func(vars []postmanVariable, key string) string {
+		for _, v := range vars {
+			if v.Key == key {
+				return imp.convert(v.value(), notes)
+			}
+		}
+		return ""
+	}
+	switch auth.Type {
+	case "noauth":
+		return nil
+	case "bearer":
+		return []genHeader{{"Authorization", "Bearer " + get(auth.Bearer, "token")}}
+	case "apikey":
+		if get(auth.APIKey, "in") != "query" {
+			return []genHeader{{get(auth.APIKey, "key"), get(auth.APIKey, "value")}}
+		}
+	}
+	*notes = append(*notes, fmt.Sprintf("The %s auth of the request wasn't imported.", auth.Type))
+	return nil
+}
+
+// convert replaces references to Postman variables in s with references to variables understood by
+// e2e. Collection variables are replaced with their values.
+func (imp *postmanImporter) convert(s string, notes *[]string) string {
+	return postmanVar.ReplaceAllStringFunc(s, func(ref string) string {
+		name := strings.TrimSpace(ref[2 : len(ref)-2])
+		switch {
+		case strings.HasPrefix(name, "$"):
+			*notes = append(*notes, fmt.Sprintf("The dynamic variable %s needs a replacement.", ref))
+			return ref
+		case imp.captured[name].field != "":
+			c := imp.captured[name]
+			if c.set != imp.set {
+				*notes = append(*notes, fmt.Sprintf("The variable %q is captured by %q, which isn't available here.", name, c.set))
+			}
+			return "$" + c.field
+		case imp.env[name]:
+			imp.used = append(imp.used, sanitize(name))
+			return "$" + sanitize(name)
+		case imp.collection[name] != "":
+			return imp.convert(imp.collection[name], notes)
+		default:
+			return "$" + sanitize(name)
+		}
+	})
+}
+
+// sanitize returns name with characters that can't be part of a variable name replaced.
+func sanitize(name string) string {
+	return nonWord.ReplaceAllString(name, "_")
+}
+
+// escape URL encodes s except for references to variables.
+func escape(s string) string {
+	escaped := ""
+	last := 0
+	for _, loc := range variable.FindAllStringIndex(s, -1) {
+		escaped += url.QueryEscape(s[last:loc[0]]) + s[loc[0]:loc[1]]
+		last = loc[1]
+	}
+	return escaped + url.QueryEscape(s[last:])
+}
+
+// variable matches references to variables understood by e2e.
+var variable = regexp.MustCompile(`\$\w+`)
+
+var postmanTemplate = `// Code generated by e2r import postman from {{ .Source }}.
+// It's meant as a starting point and can be edited freely.
+
+package {{ .Package }}
+
+import (
+	"github.com/gombrii/go-e2e"
+	"github.com/gombrii/go-e2e/addr"
+)
+{{- with .Services }}
+
+// The addresses of the services in the AddressBook were found in the collection as:
+{{- range $svc, $addr := . }}
+//   - {{ quote $svc }}: {{ $addr }}
+{{- end }}
+{{- end }}
+{{ range .Sets }}
+{{ if .Sequence -}}
+// {{ .Var }} contains the requests of {{ quote .Name }}, run in order since they capture
+// variables.
+var {{ .Var }} = e2e.Sequence{
+	Name: {{ quote .Name }},
+{{- with .Env }}
+	BeforeAll: e2e.Before{environment({{ range $i, $v := . }}{{ if $i }}, {{ end }}{{ quote $v }}{{ end }})},
+{{- end }}
+	Steps: e2e.Steps{
+{{- range .Tests }}
+		// {{ .Name }}
+		{{ template "test" . }},
+{{- end }}
+	},
+}
+{{- else -}}
+// {{ .Var }} contains the requests of {{ quote .Name }}.
+var {{ .Var }} = e2e.Suite{
+	Name: {{ quote .Name }},
+{{- with .Env }}
+	BeforeAll: e2e.Before{environment({{ range $i, $v := . }}{{ if $i }}, {{ end }}{{ quote $v }}{{ end }})},
+{{- end }}
+	Tests: e2e.Tests{
+{{- range .Tests }}
+		{{ quote .Name }}: {{ template "test" . }},
+{{- end }}
+	},
+}
+{{- end }}
+{{ end }}
+`
+
+var environmentTemplate = `// Code generated by e2r import postman.
+// It's meant as a starting point and can be edited freely.
+
+package {{ .Package }}
+
+import (
+	"strings"
+
+	"github.com/gombrii/go-e2e/addr"
+)
+
+// environment is a before-action making variables of the current environment in the AddressBook
+// available to the tests. Variables missing from the environment are left unset and reported.
+func environment(names ...string) func(data map[string]string) (string, error) {
+	return func(data map[string]string) (string, error) {
+		set, missing := []string{}, []string{}
+		for _, name := range names {
+			if !addr.Exists(name) {
+				missing = append(missing, name)
+				continue
+			}
+			data[name] = addr.Lookup(name)
+			set = append(set, name)
+		}
+		log := "environment variables set: " + strings.Join(set, ", ")
+		if len(missing) > 0 {
+			log += "; missing from the environment: " + strings.Join(missing, ", ")
+		}
+		return log, nil
+	}
+}
+`
+
+var sharedBookTemplate = `// Code generated by e2r import postman.
+// It's meant as a starting point and can be edited freely.
+
+// Package addressbook contains the environments of Postman collections.
+package addressbook
+
+import "github.com/gombrii/go-e2e/addr"
+
+// AddressBook contains the variables of the Postman environments and the addresses of the services
+// of the collections. Register it by calling addr.Set from the init hook in the root of the test
+// project.
+var AddressBook = addr.AddressBook{}
+
+// add adds the variables of the environments of book to AddressBook.
+func add(book addr.AddressBook) {
+	for env, vars := range book {
+		if AddressBook[env] == nil {
+			AddressBook[env] = map[string]string{}
+		}
+		for name, val := range vars {
+			AddressBook[env][name] = val
+		}
+	}
+}
+`
+
+var addressBookTemplate = `// Code generated by e2r import postman from {{ .Source }}.
+// It's meant as a starting point and can be edited freely.
+
+package addressbook
+
+import "github.com/gombrii/go-e2e/addr"
+
+// init adds the Postman environments of {{ .Source }}, and the services of the collection, to the
+// AddressBook.
+func init() {
+	add(addr.AddressBook{
+{{- range $env, $vars := .Envs }}
+		{{ quote $env }}: {
+{{- range $name, $val := $vars }}
+			{{ quote $name }}: {{ quote $val }},
+{{- end }}
+		},
+{{- end }}
+	})
+}
+`
@@ -3,6 +3,10 @@ package main
 var runner = `package main
 
 import (
+	flag{{ .Noise }} "flag"
+	os{{ .Noise }} "os"
+	strconv{{ .Noise }} "strconv"
+	strings{{ .Noise }} "strings"
 {{- if .Setup.PkgPath }}
 	{{ .Setup.PkgName }} "{{ .Setup.PkgPath }}"
 {{- end }}
@@ -13,19 +17,75 @@ import (
 )
 
 func main() {
-	e2e{{ .Noise }}.Runner{
-	{{- if .Setup.BeforeRun}}
-		BeforeRun: {{ .Setup.PkgName }}.{{ .Setup.BeforeRun }},
-	{{- end }}
-	{{- if .Setup.AfterRun }}
-		AfterRun: {{ .Setup.PkgName }}.{{ .Setup.AfterRun }},
-	{{- end }}
-	}.Run(
+	r{{ .Noise }} := runner{{ .Noise }}()
+{{- if .Setup.BeforeRun }}
+	r{{ .Noise }}.BeforeRun = {{ .Setup.PkgName }}.{{ .Setup.BeforeRun }}
+{{- end }}
+{{- if .Setup.AfterRun }}
+	r{{ .Noise }}.AfterRun = {{ .Setup.PkgName }}.{{ .Setup.AfterRun }}
+{{- end }}
+
+	if !r{{ .Noise }}.Run(
 {{- range .Packages }}
 	{{- $pkg := . }}
 	{{- range .ExportedVars }}
 		{{ $pkg.PkgName }}.{{ .VarName }},
 	{{- end }}
 {{- end }}
-	)
+	) {
+		os{{ .Noise }}.Exit(1)
+	}
+}
+
+// runner{{ .Noise }} configures a Runner from the flags passed by e2r after the env argument. It's
+// kept apart from main so that the flag variables never shadow any test package.
+func runner{{ .Noise }}() e2e{{ .Noise }}.Runner {
+	flags := flag{{ .Noise }}.NewFlagSet("runner", flag{{ .Noise }}.ExitOnError)
+	run := flags.String("run", "", "")
+	tags := flags.String("tags", "", "")
+	skipTags := flags.String("skip-tags", "", "")
+	timeout := flags.Duration("timeout", 0, "")
+	parallel := flags.Int("parallel", 0, "")
+	rate := flags.String("rate", "", "")
+	openapi := flags.String("openapi", "", "")
+	openapiWarn := flags.Bool("openapi-warn", false, "")
+	users := flags.Int("users", 0, "")
+	duration := flags.Duration("duration", 0, "")
+	ci := flags.Bool("ci", false, "")
+	logs := flags.String("logs", "", "")
+	junit := flags.String("junit", "", "")
+	html := flags.String("html", "", "")
+	json := flags.String("json", "", "")
+	ndjson := flags.String("ndjson", "", "")
+	flags.Parse(os{{ .Noise }}.Args[2:])
+
+	return e2e{{ .Noise }}.Runner{
+		CI:         *ci,
+		Logs:       e2e{{ .Noise }}.Logs(*logs),
+		JUnit:      *junit,
+		HTML:       *html,
+		JSON:       *json,
+		NDJSON:     *ndjson,
+		Filter:     *run,
+		Tags:       split{{ .Noise }}(*tags),
+		SkipTags:   split{{ .Noise }}(*skipTags),
+		Timeout:    *timeout,
+		Parallel:   *parallel,
+		RateLimits: rates{{ .Noise }}(*rate),
+		OpenAPI:    e2e{{ .Noise }}.OpenAPI{Spec: *openapi, Warn: *openapiWarn},
+		Load:       e2e{{ .Noise }}.Load{Users: *users, Duration: *duration},
+	}
+}
+
+func split{{ .Noise }}(list string) []string {
+	return strings{{ .Noise }}.FieldsFunc(list, func(r rune) bool { return r == ',' })
+}
+
+func rates{{ .Noise }}(list string) map[string]float64 {
+	rates := map[string]float64{}
+	for _, rate := range split{{ .Noise }}(list) {
+		host, rps, _ := strings{{ .Noise }}.Cut(rate, "=")
+		rates[host], _ = strconv{{ .Noise }}.ParseFloat(rps, 64)
+	}
+	return rates
 }`
@@ -0,0 +1,15 @@
+// Package fixture is a test project importing the addr package of go-e2e, used to test loading
+// packages that import other packages.
+package fixture
+
+import (
+	"github.com/gombrii/go-e2e"
+	"github.com/gombrii/go-e2e/addr"
+)
+
+var Users = e2e.Suite{
+	Name: "users",
+	Tests: e2e.Tests{
+		"get": {Request: e2e.Request{Method: "GET", URL: addr.Lookup("users") + "/users"}},
+	},
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	e2e "github.com/gombrii/go-e2e"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+// watch runs the tests matched by pattern and then reruns them whenever a Go file in the project,
+// or in the location matched by pattern, changes. After each run it prints what changed since the
+// previous run. It never returns.
+func watch(wd, pattern, env string, opts options) {
+	if opts.logs == "" {
+		opts.logs = "failed" // Don't prompt on every run
+	}
+	dirs := []string{moduleRoot(wd)}
+	if dir, _ := separate(wd, pattern); !strings.HasPrefix(dir, dirs[0]) {
+		dirs = append(dirs, strings.TrimSuffix(dir, "..."))
+	}
+	reportFile := filepath.Join(os.TempDir(), fmt.Sprintf("e2r-watch-%d.json", os.Getpid()))
+	defer os.Remove(reportFile)
+
+	var previous map[string]string
+	files := scan(dirs)
+	for {
+		os.Remove(reportFile)
+		if _, err := run(wd, pattern, env, opts.noCache, append(opts.runnerArgs(), "-json="+reportFile)); err != nil {
+			fmt.Printf("Error %v\n", err)
+		} else if current, err := readResults(reportFile); err != nil {
+			fmt.Printf("Error reading results: %v\n", err)
+		} else {
+			if previous != nil {
+				printDiff(previous, current)
+			}
+			previous = current
+		}
+
+		fmt.Println("\nWatching for changes, press Ctrl+C to quit...")
+		for {
+			time.Sleep(pollInterval)
+			if next := scan(dirs); !maps.Equal(files, next) {
+				files = next
+				break
+			}
+		}
+		fmt.Println("\n=== Change detected, rerunning ===")
+	}
+}
+
+// scan returns the modification times of all Go files and module files within dirs.
+func scan(dirs []string) map[string]time.Time {
+	files := make(map[string]time.Time)
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") && d.Name() != "go.mod" && d.Name() != "go.sum" {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// moduleRoot returns the closest directory from wd and up containing a go.mod file, or wd if
+// there is none.
+func moduleRoot(wd string) string {
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return wd
+		}
+	}
+}
+
+// readResults reads the JSON report written by the runner into a map of test paths, ie.
+// "SetName/testName", to their outcome.
+func readResults(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report e2e.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]string)
+	for _, set := range report.Sets {
+		for _, test := range set.Tests {
+			outcome := "fail"
+			switch {
+			case test.Skipped:
+				outcome = "skip"
+			case test.Passed:
+				outcome = "pass"
+			}
+			results[set.Name+"/"+test.Name] = outcome
+		}
+	}
+	return results, nil
+}
+
+// printDiff prints a compact summary of which tests changed outcome between two runs.
+func printDiff(previous, current map[string]string) {
+	lines := []string{}
+	for path, now := range current {
+		before, ok := previous[path]
+		switch {
+		case !ok:
+			lines = append(lines, fmt.Sprintf("  NEW      %s (%s)", path, now))
+		case before == now:
+		case now == "pass":
+			lines = append(lines, fmt.Sprintf("  FIXED    %s", path))
+		case now == "fail":
+			lines = append(lines, fmt.Sprintf("  BROKEN   %s", path))
+		default:
+			lines = append(lines, fmt.Sprintf("  %-8s %s", strings.ToUpper(now), path))
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			lines = append(lines, fmt.Sprintf("  REMOVED  %s", path))
+		}
+	}
+	slices.SortFunc(lines, func(a, b string) int { return strings.Compare(a[11:], b[11:]) })
+
+	fmt.Println("\n---------------------------------\nCHANGES SINCE LAST RUN")
+	if len(lines) == 0 {
+		fmt.Println("  No test changed outcome")
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,29 @@
+package e2e
+
+import (
+	"regexp"
+	"slices"
+)
+
+// Tags label Suites, Sequences and tests with arbitrary properties, eg. "smoke" or "destructive",
+// which can be used to select what to run. Tests inherit the tags of the set they belong to.
+type Tags []string
+
+// filter decides which sets and tests are run.
+type filter struct {
+	pattern  *regexp.Regexp
+	tags     []string // At least one of these is required, if any
+	skipTags []string // None of these are allowed
+}
+
+// matches reports whether the test or set at path with the given tags is selected. Tests in Suites
+// have the path "SuiteName/testName" while Sequences are matched by their name only.
+func (f filter) matches(path string, tags Tags) bool {
+	if f.pattern != nil && !f.pattern.MatchString(path) {
+		return false
+	}
+	if len(f.tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(f.tags, tag) }) {
+		return false
+	}
+	return !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(f.skipTags, tag) })
+}
@@ -0,0 +1,150 @@
+package e2e
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlFile is a [Reporter] writing an HTML report to the file at path once the run has ended.
+type htmlFile struct {
+	NopReporter
+	path string
+}
+
+func (h htmlFile) RunEnd(report Report) {
+	file, err := os.Create(h.path)
+	if err != nil {
+		fmt.Printf("\n%s: writing HTML report: creating file: %v\n", pink("ERROR"), err)
+		return
+	}
+	defer file.Close()
+
+	if err := report.WriteHTML(file); err != nil {
+		fmt.Printf("\n%s: writing HTML report: %v\n", pink("ERROR"), err)
+	}
+}
+
+// WriteHTML writes the report to w as a single self-contained HTML page listing every set with
+// collapsible details of each test.
+func (r Report) WriteHTML(w io.Writer) error {
+	numTests, numFailed := 0, 0
+	for _, set := range r.Sets {
+		for _, test := range set.Tests {
+			numTests++
+			if !test.Passed && !test.Skipped {
+				numFailed++
+			}
+		}
+	}
+
+	err := htmlReport.Execute(w, struct {
+		Report
+		Generated time.Time
+		NumTests  int
+		NumFailed int
+	}{r, time.Now(), numTests, numFailed})
+	if err != nil {
+		return fmt.Errorf("executing template: %v", err)
+	}
+	return nil
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return fmt.Sprintf("%.0f ms", float64(d)/float64(time.Millisecond))
+	},
+	"reqBody": func(req Request) string {
+		if req.Body == "" {
+			return ""
+		}
+		return format([]byte(req.Body), req.Content)
+	},
+	"respBody": func(resp Response) string {
+		if resp.Body == "" {
+			return ""
+		}
+		return format([]byte(resp.Body), resp.Headers.Get("Content-Type"))
+	},
+	"headers": func(h map[string][]string) []string {
+		lines := []string{}
+		for k, v := range h {
+			lines = append(lines, k+": "+strings.Join(v, "; "))
+		}
+		sort.Strings(lines)
+		return lines
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>e2e report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #777; margin-bottom: 2em; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; }
+.skip { color: #777; }
+.set { border: 1px solid #ddd; border-radius: 6px; margin-bottom: 1.5em; }
+.set > h2 { margin: 0; padding: 0.6em 1em; background: #f6f8fa; border-bottom: 1px solid #ddd; font-size: 1.1em; }
+details { border-bottom: 1px solid #eee; padding: 0.4em 1em; }
+details:last-child { border-bottom: none; }
+summary { cursor: pointer; }
+.duration { color: #777; float: right; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; border-radius: 4px; }
+.failure { background: #ffebe9; color: #cf222e; }
+h4 { margin: 0.8em 0 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>Test report <span class="{{ if .Passed }}pass">PASSED{{ else }}fail">FAILED{{ end }}</span></h1>
+<div class="meta">
+{{ len .Sets }} sets, {{ .NumTests }} tests, {{ .NumFailed }} failed, run in {{ ms .Duration }}. Generated {{ .Generated.Format "2006-01-02 15:04:05" }}.
+</div>
+{{- range .Sets }}
+<div class="set">
+<h2><span class="{{ if .Passed }}pass">&#10004;{{ else }}fail">&#10008;{{ end }}</span> {{ .Name }}<span class="duration">{{ ms .Duration }}</span></h2>
+{{- range .Tests }}
+<details>
+<summary>
+{{- if .Skipped }}<span class="skip">&#8211; {{ .Name }} (skipped)</span>
+{{- else if .Passed }}<span class="pass">&#10004;</span> {{ .Name }}
+{{- else }}<span class="fail">&#10008;</span> {{ .Name }}{{ end }}
+{{- if gt .Attempts 1 }} <span class="skip">({{ .Attempts }} attempts)</span>{{ end }}
+<span class="duration">{{ ms .Duration }}</span></summary>
+{{- range .Failures }}
+<pre class="failure">{{ . }}</pre>
+{{- end }}
+{{- if not .Skipped }}
+<h4>Request</h4>
+<pre>{{ .Request.Method }} {{ .Request.URL }}
+{{- range .Request.Headers }}
+{{ .Key }}: {{ .Val }}{{ end }}
+{{ with reqBody .Request }}
+{{ . }}{{ end }}</pre>
+{{- end }}
+{{- with .Response }}
+<h4>Response</h4>
+<pre>{{ .Status }} ({{ .Timing }})
+{{- range headers .Headers }}
+{{ . }}{{ end }}
+{{ with respBody . }}
+{{ . }}{{ end }}</pre>
+{{- end }}
+{{- with .Captured }}
+<h4>Captured</h4>
+<pre>{{ range $k, $v := . }}{{ $k }} = {{ $v }}
+{{ end }}</pre>
+{{- end }}
+</details>
+{{- end }}
+</div>
+{{- end }}
+</body>
+</html>
+`))
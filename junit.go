@@ -0,0 +1,117 @@
+package e2e
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name     string          `xml:"name,attr"`
+		Tests    int             `xml:"tests,attr"`
+		Failures int             `xml:"failures,attr"`
+		Skipped  int             `xml:"skipped,attr"`
+		Time     string          `xml:"time,attr"`
+		Cases    []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		Skipped   *struct{}     `xml:"skipped,omitempty"`
+		SystemOut *junitOutput  `xml:"system-out,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Body    string `xml:",cdata"`
+	}
+	junitOutput struct {
+		Text string `xml:",cdata"`
+	}
+)
+
+// junitFile is a [Reporter] writing a JUnit XML report to the file at path once the run has ended.
+type junitFile struct {
+	NopReporter
+	path string
+}
+
+func (j junitFile) RunEnd(report Report) {
+	file, err := os.Create(j.path)
+	if err != nil {
+		fmt.Printf("\n%s: writing JUnit report: creating file: %v\n", pink("ERROR"), err)
+		return
+	}
+	defer file.Close()
+
+	if err := report.WriteJUnit(file); err != nil {
+		fmt.Printf("\n%s: writing JUnit report: %v\n", pink("ERROR"), err)
+	}
+}
+
+// WriteJUnit writes the report to w in JUnit XML format. Each set is mapped to a <testsuite> and
+// each test or step within it to a <testcase>.
+func (r Report) WriteJUnit(w io.Writer) error {
+	out := junitTestSuites{Time: seconds(r.Duration)}
+
+	for _, set := range r.Sets {
+		suite := junitTestSuite{
+			Name:  set.Name,
+			Tests: len(set.Tests),
+			Time:  seconds(set.Duration),
+		}
+		for _, test := range set.Tests {
+			tc := junitTestCase{
+				Name:      test.Name,
+				Classname: set.Name,
+				Time:      seconds(test.Duration),
+			}
+			if test.Log != "" {
+				tc.SystemOut = &junitOutput{ansi.ReplaceAllString(test.Log, "")}
+			}
+			switch {
+			case test.Skipped:
+				tc.Skipped = &struct{}{}
+				suite.Skipped++
+			case !test.Passed:
+				msg := strings.Join(test.Failures, "\n")
+				tc.Failure = &junitFailure{Message: msg, Body: msg}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Suites = append(out.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing header: %v", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("encoding report: %v", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
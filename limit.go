@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// limiter caps the number of requests in flight and the rate of requests per host. It's shared
+// by all sets in a run.
+type limiter struct {
+	slots chan struct{}      // Caps requests in flight, nil if unbounded
+	rates map[string]float64 // Requests per second by host, "*" applying to any other host
+
+	mu   sync.Mutex
+	next map[string]time.Time // Earliest time of the next request by host
+}
+
+func newLimiter(parallel int, rates map[string]float64) *limiter {
+	l := &limiter{rates: rates, next: make(map[string]time.Time)}
+	if parallel > 0 {
+		l.slots = make(chan struct{}, parallel)
+	}
+	return l
+}
+
+// acquire blocks until a request to rawURL may be made without breaking any limit. The returned
+// release func must be called once the request, including reading its body, is done.
+func (l *limiter) acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if wait := l.reserve(rawURL); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// reserve reserves the next free slot in time for a request to rawURL according to the rate limit
+// of its host and returns how long to wait for it.
+func (l *limiter) reserve(rawURL string) time.Duration {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	host := u.Host
+	rate, ok := l.rates[host]
+	if !ok {
+		host = u.Hostname()
+		rate, ok = l.rates[host]
+	}
+	if !ok {
+		rate, ok = l.rates["*"]
+	}
+	if !ok || rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(time.Duration(float64(time.Second) / rate))
+	return at.Sub(now)
+}
@@ -0,0 +1,195 @@
+package e2e
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Load configures a load test, running sets repeatedly with a number of virtual users rather than
+// once.
+type Load struct {
+	// Users is the number of virtual users running the sets concurrently. Each user runs all sets
+	// one after the other, over and over, each run of a set having its own variables.
+	Users int
+	// Duration is for how long the users keep running the sets. Runs in progress when the time is
+	// up are completed. Each user runs the sets once if left unset.
+	Duration time.Duration
+}
+
+type (
+	// LoadReport contains the outcome of a load test.
+	LoadReport struct {
+		// Passed is true if no test failed during the load test.
+		Passed   bool
+		Users    int
+		Duration time.Duration
+		// Iterations is the number of times users ran all sets.
+		Iterations int
+		Requests   int
+		Errors     int
+		// Tests contains statistics of every test and sequence step, ordered by set name and then
+		// by the order in which tests were first run.
+		Tests []LoadStats
+	}
+	// LoadStats contains statistics of a test or sequence step during a load test.
+	LoadStats struct {
+		Set      string
+		Test     string
+		Requests int
+		Errors   int
+		// Throughput is the number of requests per second.
+		Throughput float64
+		// Latency percentiles of the HTTP calls of the test, excluding before- and after-actions,
+		// retry backoff and waiting for rate limits. Runs without a response have no latency.
+		P50, P90, P95, P99, Max time.Duration
+	}
+)
+
+// RunLoad runs the sets as a load test according to r.Load and returns statistics of the run. Tests
+// are run without prompts and produce no output, leaving Reporters and report files unused.
+func (r Runner) RunLoad(sets ...Set) LoadReport {
+	r.CI = true
+	interactive = false // Nobody can answer prompts of many users at once
+	sets, err := r.selectSets(sets)
+	if err != nil {
+		fmt.Printf("%s: %v\n", pink("ERROR"), err)
+		return LoadReport{}
+	}
+
+	r.ensureHooks()
+	before := r.BeforeRun()
+	defer r.AfterRun(before)
+
+	stats := &loadCollector{tests: make(map[[2]string]*loadSamples)}
+	ses, err := r.session(stats)
+	if err != nil {
+		fmt.Printf("%s: %v\n", pink("ERROR"), err)
+		return LoadReport{}
+	}
+	users := max(r.Load.Users, 1)
+	start := time.Now()
+	deadline := start.Add(r.Load.Duration)
+	iterations := make(chan int)
+
+	for range users {
+		go func() {
+			n := 0
+			for n == 0 || time.Now().Before(deadline) {
+				for _, st := range sets {
+					st.Run(ses)
+				}
+				n++
+			}
+			iterations <- n
+		}()
+	}
+	report := LoadReport{Users: users}
+	for range users {
+		report.Iterations += <-iterations
+	}
+	report.Duration = time.Since(start)
+
+	for _, key := range stats.order {
+		test := stats.tests[key]
+		slices.Sort(test.latencies)
+		report.Tests = append(report.Tests, LoadStats{
+			Set:        key[0],
+			Test:       key[1],
+			Requests:   test.requests,
+			Errors:     test.errors,
+			Throughput: float64(test.requests) / report.Duration.Seconds(),
+			P50:        percentile(test.latencies, 50),
+			P90:        percentile(test.latencies, 90),
+			P95:        percentile(test.latencies, 95),
+			P99:        percentile(test.latencies, 99),
+			Max:        percentile(test.latencies, 100),
+		})
+		report.Requests += test.requests
+		report.Errors += test.errors
+	}
+	slices.SortStableFunc(report.Tests, func(a, b LoadStats) int { return strings.Compare(a.Set, b.Set) })
+	report.Passed = report.Errors == 0
+	return report
+}
+
+// Print prints a summary of the load test to w.
+func (l LoadReport) Print(w io.Writer) {
+	rate := func(n int) float64 {
+		if l.Requests == 0 {
+			return 0
+		}
+		return float64(n) / float64(l.Requests) * 100
+	}
+	fmt.Fprintf(w, `
+---------------------------------
+LOAD TEST RESULT: %s
+Users: %14d
+Duration: %11v
+Iterations: %9d
+Requests: %11d (%.1f/s)
+Errors: %13d (%.2f%%)
+---------------------------------
+`, resultText(l.Passed), l.Users, l.Duration.Round(time.Millisecond), l.Iterations, l.Requests, float64(l.Requests)/l.Duration.Seconds(), l.Errors, rate(l.Errors))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tREQS\tREQ/S\tERR%\tP50\tP90\tP95\tP99\tMAX\t")
+	for _, t := range l.Tests {
+		fmt.Fprintf(tw, "%s/%s\t%d\t%.1f\t%.2f\t%v\t%v\t%v\t%v\t%v\t\n", t.Set, t.Test, t.Requests, t.Throughput,
+			float64(t.Errors)/float64(max(t.Requests, 1))*100, round(t.P50), round(t.P90), round(t.P95), round(t.P99), round(t.Max))
+	}
+	tw.Flush()
+}
+
+// loadCollector is a [Reporter] collecting the latency and outcome of every test run during a load
+// test. Latencies are taken from the timings of the HTTP calls rather than the durations of the
+// tests, which include before-actions, retry backoff and waiting for rate limits.
+type loadCollector struct {
+	NopReporter
+	mu    sync.Mutex
+	order [][2]string                // Set and test names in the order first run
+	tests map[[2]string]*loadSamples // By set and test name
+}
+
+// loadSamples contains the samples collected for a test.
+type loadSamples struct {
+	requests  int
+	errors    int
+	latencies []time.Duration
+}
+
+func (c *loadCollector) TestEnd(set string, test TestReport) {
+	if test.Skipped {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := [2]string{set, test.Name}
+	samples, ok := c.tests[key]
+	if !ok {
+		samples = &loadSamples{}
+		c.tests[key] = samples
+		c.order = append(c.order, key)
+	}
+	samples.requests += test.Attempts
+	if !test.Passed {
+		samples.errors++
+	}
+	if test.Response != nil {
+		samples.latencies = append(samples.latencies, test.Response.Timing.Total)
+	}
+}
+
+// percentile returns the pth percentile of sorted samples using the nearest rank method, or 0 if
+// there are no samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,86 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// NDJSON is a [Reporter] streaming one JSON object per event and line to W as the run happens.
+type NDJSON struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+type ndjsonEvent struct {
+	Event    string            `json:"event"`
+	Time     time.Time         `json:"time"`
+	Set      string            `json:"set,omitempty"`
+	Test     string            `json:"test,omitempty"`
+	NumSets  int               `json:"num_sets,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Failure  string            `json:"failure,omitempty"`
+	Passed   *bool             `json:"passed,omitempty"`
+	Skipped  bool              `json:"skipped,omitempty"`
+	Attempts int               `json:"attempts,omitempty"`
+	Duration float64           `json:"duration,omitempty"` // Seconds
+	Failures []string          `json:"failures,omitempty"`
+	Captured map[string]string `json:"captured,omitempty"`
+}
+
+func (n *NDJSON) RunStart(numSets int) {
+	n.write(ndjsonEvent{Event: "run_start", NumSets: numSets})
+}
+
+func (n *NDJSON) SetStart(set string) {
+	n.write(ndjsonEvent{Event: "set_start", Set: set})
+}
+
+func (n *NDJSON) TestStart(set, test string) {
+	n.write(ndjsonEvent{Event: "test_start", Set: set, Test: test})
+}
+
+func (n *NDJSON) RequestSent(set, test string, req Request) {
+	n.write(ndjsonEvent{Event: "request_sent", Set: set, Test: test, Method: req.Method, URL: req.URL})
+}
+
+func (n *NDJSON) ResponseReceived(set, test string, resp Response) {
+	n.write(ndjsonEvent{Event: "response_received", Set: set, Test: test, Status: resp.Status})
+}
+
+func (n *NDJSON) AssertionFailed(set, test string, failure string) {
+	n.write(ndjsonEvent{Event: "assertion_failed", Set: set, Test: test, Failure: failure})
+}
+
+func (n *NDJSON) TestEnd(set string, test TestReport) {
+	n.write(ndjsonEvent{
+		Event:    "test_end",
+		Set:      set,
+		Test:     test.Name,
+		Passed:   &test.Passed,
+		Skipped:  test.Skipped,
+		Attempts: test.Attempts,
+		Duration: test.Duration.Seconds(),
+		Failures: test.Failures,
+		Captured: test.Captured,
+	})
+}
+
+func (n *NDJSON) SetEnd(set SetReport) {
+	n.write(ndjsonEvent{Event: "set_end", Set: set.Name, Passed: &set.Passed, Duration: set.Duration.Seconds()})
+}
+
+func (n *NDJSON) RunEnd(report Report) {
+	n.write(ndjsonEvent{Event: "run_end", NumSets: len(report.Sets), Passed: &report.Passed, Duration: report.Duration.Seconds()})
+}
+
+func (n *NDJSON) write(event ndjsonEvent) {
+	event.Time = time.Now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	json.NewEncoder(n.W).Encode(event)
+}
@@ -0,0 +1,146 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// OpenAPI configures validation of every request and response against an OpenAPI 3 spec. The
+// request must match an operation of the spec, the response status must be documented by the
+// operation and parameters, headers and bodies must match their schemas.
+type OpenAPI struct {
+	// Spec is the path of an OpenAPI 3 spec in YAML or JSON. No validation is made if left empty.
+	Spec string
+	// Warn reports violations as warnings in the test logs instead of failing the tests.
+	Warn bool
+}
+
+// contract validates requests and responses against an OpenAPI spec.
+type contract struct {
+	router routers.Router
+	warn   bool
+}
+
+// loadContract loads the spec of o, returning nil if no spec is set.
+func loadContract(o OpenAPI) (*contract, error) {
+	if o.Spec == "" {
+		return nil, nil
+	}
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(o.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading OpenAPI spec: %v", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+
+	// Tests target different hosts depending on env, so operations are matched by path only
+	doc.Servers = pathsOnly(doc.Servers)
+	for _, item := range doc.Paths.Map() {
+		item.Servers = pathsOnly(item.Servers)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("routing OpenAPI spec: %v", err)
+	}
+	return &contract{router, o.Warn}, nil
+}
+
+// pathsOnly returns servers with the scheme and host removed from their URLs.
+func pathsOnly(servers openapi3.Servers) openapi3.Servers {
+	paths := openapi3.Servers{}
+	for _, server := range servers {
+		stripped := *server
+		if _, rest, ok := strings.Cut(server.URL, "://"); ok {
+			stripped.URL = ""
+			if i := strings.Index(rest, "/"); i >= 0 {
+				stripped.URL = rest[i:]
+			}
+		}
+		paths = append(paths, &stripped)
+	}
+	return paths
+}
+
+// validate validates the request req and the response to it, returning one message per violation.
+func (c *contract) validate(ctx context.Context, req Request, status int, header http.Header, body []byte) []string {
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, strings.NewReader(req.Body))
+	if err != nil {
+		return []string{fmt.Sprintf("request: %v", err)}
+	}
+	for _, h := range req.Headers {
+		httpReq.Header.Add(h.Key, h.Val)
+	}
+
+	route, params, err := c.router.FindRoute(httpReq)
+	if err != nil {
+		return []string{fmt.Sprintf("request: no operation matches %s %s: %v", req.Method, httpReq.URL.Path, err)}
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+	options.WithCustomSchemaErrorFunc(func(err *openapi3.SchemaError) string {
+		return fmt.Sprintf("%q: %s", "/"+strings.Join(err.JSONPointer(), "/"), err.Reason)
+	})
+	reqInput := &openapi3filter.RequestValidationInput{
+		Request:    httpReq,
+		PathParams: params,
+		Route:      route,
+		Options:    options,
+	}
+	violations := describe("request", openapi3filter.ValidateRequest(ctx, reqInput))
+
+	respInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: reqInput,
+		Status:                 status,
+		Header:                 header,
+		Options:                options,
+	}
+	respInput.SetBodyBytes(body)
+	return append(violations, describe("response", openapi3filter.ValidateResponse(ctx, respInput))...)
+}
+
+// describe returns one message prefixed with label per error in err.
+func describe(label string, err error) []string {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case openapi3.MultiError:
+		messages := []string{}
+		for _, e := range err {
+			messages = append(messages, describe(label, e)...)
+		}
+		return messages
+	case *openapi3filter.RequestError:
+		if multi, ok := err.Err.(openapi3.MultiError); ok { // Describe each error in its context
+			messages := []string{}
+			for _, e := range multi {
+				messages = append(messages, describe(label, &openapi3filter.RequestError{
+					Parameter: err.Parameter, RequestBody: err.RequestBody, Reason: err.Reason, Err: e,
+				})...)
+			}
+			return messages
+		}
+	case *openapi3filter.ResponseError:
+		if multi, ok := err.Err.(openapi3.MultiError); ok {
+			messages := []string{}
+			for _, e := range multi {
+				messages = append(messages, describe(label, &openapi3filter.ResponseError{Reason: err.Reason, Err: e})...)
+			}
+			return messages
+		}
+	}
+	return []string{fmt.Sprintf("%s: %v", label, err)}
+}
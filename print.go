@@ -11,6 +11,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 var progressBarMutex = sync.Mutex{}
@@ -45,6 +46,11 @@ func grey(text ...any) string {
 	return fmt.Sprintf("\033[38;5;244m%v\033[0m", fmt.Sprint(text...))
 }
 
+// round returns d rounded to a precision suitable for printing request timings.
+func round(d time.Duration) string {
+	return d.Round(100 * time.Microsecond).String()
+}
+
 func resultText(success bool) string {
 	if success {
 		return green("SUCCESS")
@@ -91,7 +97,10 @@ func format(data []byte, contentType string) string {
 	return out.String() + "\n"
 }
 
-func drawProgressBar(results []result, total int) {
+func drawProgressBar(results []SetReport, total int) {
+	if total == 0 {
+		return
+	}
 	numDash := 48
 	segSize := int(math.Max(float64(numDash)/float64(total), 1))
 	numDash = int(math.Min(float64(total*segSize), float64(numDash)))
@@ -107,7 +116,7 @@ func drawProgressBar(results []result, total int) {
 
 		fail := false
 		for _, test := range results[start:end] {
-			if !test.passed {
+			if !test.Passed {
 				fail = true
 				break
 			}
@@ -0,0 +1,122 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type (
+	// Report is the structured outcome of a run as returned by [Runner.RunReport].
+	Report struct {
+		// Passed is true if all sets passed.
+		Passed bool
+		// Duration is the wall time of the whole run.
+		Duration time.Duration
+		// Sets contains one SetReport per Suite or Sequence run, sorted by name.
+		Sets []SetReport
+	}
+	// SetReport is the outcome of running a single Suite or Sequence.
+	SetReport struct {
+		// The name of the Suite or Sequence.
+		Name string
+		// Passed is true if all tests in the set passed.
+		Passed bool
+		// Duration is the wall time of running the set.
+		Duration time.Duration
+		// Tests contains one TestReport per test, sorted by name for Suites and in order for
+		// Sequences.
+		Tests []TestReport
+		// Log is the full text log of the set as printed after a run.
+		Log string
+	}
+	// TestReport is the outcome of a single test in a Suite or step in a Sequence.
+	TestReport struct {
+		// The name of the test. Steps are named "step 1", "step 2", etc.
+		Name string
+		// Passed is true if the test met all expectations.
+		Passed bool
+		// Skipped is true if the test was never run, eg. a step following a failed step.
+		Skipped bool
+		// Request is the request as it was sent, with variables injected.
+		Request Request
+		// Response is the response received. It's nil if no response was received.
+		Response *Response
+		// Failures contains the reasons the test failed. It's empty if the test passed.
+		Failures []string
+		// Warnings contains violations of the OpenAPI spec of the Runner when it's set to warn.
+		Warnings []string
+		// Attempts is the number of times the HTTP call was made. It's more than 1 if the test was
+		// retried.
+		Attempts int
+		// Duration is the wall time of the test, including any before-actions and retries.
+		Duration time.Duration
+		// Captured contains the values captured from the response body by the test's Captors.
+		Captured map[string]string
+		// Log is the text log of the test.
+		Log string
+	}
+	// Response is an HTTP response as received by a test.
+	Response struct {
+		Status  int
+		Headers http.Header
+		Body    string
+		// Timing is how long the phases of the HTTP call took.
+		Timing Timing
+	}
+	// Timing contains the durations of the phases of an HTTP call, each measured from when the
+	// phase started. DNS, Connect and TLS are zero if a connection was reused.
+	Timing struct {
+		DNS     time.Duration
+		Connect time.Duration
+		TLS     time.Duration
+		// TTFB is the time from sending the request until the first byte of the response arrived.
+		TTFB time.Duration
+		// Total is the time from sending the request until the whole response body was read.
+		Total time.Duration
+	}
+)
+
+func (t Timing) String() string {
+	phases := []string{}
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{{"dns", t.DNS}, {"connect", t.Connect}, {"tls", t.TLS}, {"ttfb", t.TTFB}, {"total", t.Total}} {
+		if phase.d > 0 {
+			phases = append(phases, fmt.Sprintf("%s %s", phase.name, round(phase.d)))
+		}
+	}
+	return strings.Join(phases, ", ")
+}
+
+// NumRun returns the number of tests that were run, not counting skipped ones.
+func (s SetReport) NumRun() int {
+	numRun := 0
+	for _, t := range s.Tests {
+		if !t.Skipped {
+			numRun++
+		}
+	}
+	return numRun
+}
+
+// jsonFile is a [Reporter] writing the report as JSON to the file at path once the run has ended.
+type jsonFile struct {
+	NopReporter
+	path string
+}
+
+func (j jsonFile) RunEnd(report Report) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Printf("\n%s: writing JSON report: %v\n", pink("ERROR"), err)
+		return
+	}
+	if err := os.WriteFile(j.path, data, 0o644); err != nil {
+		fmt.Printf("\n%s: writing JSON report: %v\n", pink("ERROR"), err)
+	}
+}
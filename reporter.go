@@ -0,0 +1,101 @@
+package e2e
+
+// Reporter receives events as a run progresses and is what renders output of a run. Sets run
+// concurrently and so do tests within a Suite, which means a Reporter must be safe for concurrent
+// use.
+//
+// Tests are identified by the name of the set they belong to together with their own name.
+//
+// Embed [NopReporter] to only implement the events of interest.
+type Reporter interface {
+	// RunStart is called once before any set is run.
+	RunStart(numSets int)
+	// SetStart is called when a Suite or Sequence starts running.
+	SetStart(set string)
+	// TestStart is called when a test starts running, before any before-actions.
+	TestStart(set, test string)
+	// RequestSent is called right before the HTTP request of a test is made.
+	RequestSent(set, test string, req Request)
+	// ResponseReceived is called once the whole HTTP response of a test has been received.
+	ResponseReceived(set, test string, resp Response)
+	// AssertionFailed is called for every reason a test fails, be it an unmet expectation or an
+	// error making the request.
+	AssertionFailed(set, test string, failure string)
+	// TestEnd is called when a test has finished.
+	TestEnd(set string, test TestReport)
+	// SetEnd is called when a Suite or Sequence has finished.
+	SetEnd(set SetReport)
+	// RunEnd is called once after all sets have finished.
+	RunEnd(report Report)
+}
+
+// NopReporter implements all events of [Reporter] by doing nothing. It can be embedded in custom
+// reporters that only need some of the events.
+type NopReporter struct{}
+
+func (NopReporter) RunStart(int)                              {}
+func (NopReporter) SetStart(string)                           {}
+func (NopReporter) TestStart(string, string)                  {}
+func (NopReporter) RequestSent(string, string, Request)       {}
+func (NopReporter) ResponseReceived(string, string, Response) {}
+func (NopReporter) AssertionFailed(string, string, string)    {}
+func (NopReporter) TestEnd(string, TestReport)                {}
+func (NopReporter) SetEnd(SetReport)                          {}
+func (NopReporter) RunEnd(Report)                             {}
+
+// reporters fans out every event to all its reporters in order.
+type reporters []Reporter
+
+func (rs reporters) RunStart(numSets int) {
+	for _, r := range rs {
+		r.RunStart(numSets)
+	}
+}
+
+func (rs reporters) SetStart(set string) {
+	for _, r := range rs {
+		r.SetStart(set)
+	}
+}
+
+func (rs reporters) TestStart(set, test string) {
+	for _, r := range rs {
+		r.TestStart(set, test)
+	}
+}
+
+func (rs reporters) RequestSent(set, test string, req Request) {
+	for _, r := range rs {
+		r.RequestSent(set, test, req)
+	}
+}
+
+func (rs reporters) ResponseReceived(set, test string, resp Response) {
+	for _, r := range rs {
+		r.ResponseReceived(set, test, resp)
+	}
+}
+
+func (rs reporters) AssertionFailed(set, test string, failure string) {
+	for _, r := range rs {
+		r.AssertionFailed(set, test, failure)
+	}
+}
+
+func (rs reporters) TestEnd(set string, test TestReport) {
+	for _, r := range rs {
+		r.TestEnd(set, test)
+	}
+}
+
+func (rs reporters) SetEnd(set SetReport) {
+	for _, r := range rs {
+		r.SetEnd(set)
+	}
+}
+
+func (rs reporters) RunEnd(report Report) {
+	for _, r := range rs {
+		r.RunEnd(report)
+	}
+}
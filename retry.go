@@ -0,0 +1,52 @@
+package e2e
+
+import (
+	"slices"
+	"time"
+)
+
+type (
+	// Retry is a policy for retrying a failing test. The zero value means no retries.
+	Retry struct {
+		// Attempts is the maximum number of attempts, including the first one.
+		Attempts int
+		// Backoff is the time to wait before the first retry. It's doubled for each following
+		// retry.
+		Backoff time.Duration
+		// On lists the conditions under which to retry. If left empty connection errors and 5xx
+		// statuses are retried.
+		On []RetryOn
+	}
+	// RetryOn is a condition under which a failing test is retried.
+	RetryOn int
+)
+
+const (
+	RetryOnError   RetryOn = iota + 1 // Retry when no response could be received, eg. connection errors.
+	RetryOn5xx                        // Retry when the response status is 5xx.
+	RetryOnFailure                    // Retry on any failure, including unmet expectations.
+)
+
+// retries reports whether the failed result of an attempt should be retried according to r.
+func (r Retry) retries(result TestReport) bool {
+	on := r.On
+	if len(on) == 0 {
+		on = []RetryOn{RetryOnError, RetryOn5xx}
+	}
+	return slices.ContainsFunc(on, func(cond RetryOn) bool {
+		switch cond {
+		case RetryOnError:
+			return result.Response == nil
+		case RetryOn5xx:
+			return result.Response != nil && result.Response.Status >= 500
+		case RetryOnFailure:
+			return true
+		}
+		return false
+	})
+}
+
+// backoff returns the time to wait before the given attempt, the first retry being attempt 2.
+func (r Retry) backoff(attempt int) time.Duration {
+	return r.Backoff << (attempt - 2)
+}
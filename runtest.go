@@ -3,81 +3,186 @@ package e2e
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
-type testResult struct {
-	buf    *bytes.Buffer
-	passed bool
-}
-
-func performTest(client *http.Client, buf *bytes.Buffer, req Request, expected Expect) (parsedBody map[string][]string, res testResult) {
+func performTest(ses Session, set, name string, buf *bytes.Buffer, req Request, expected Expect) (parsedBody map[string][]string, res TestReport) {
 	printReq(buf, req)
+	res.Request = req
+
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = ses.Timeout
+	}
+	if req.CTX == nil {
+		req.CTX = context.Background()
+	}
+	release, err := ses.limiter.acquire(req.CTX, req.URL) // Before the timeout starts ticking
+	if err != nil {
+		return map[string][]string{}, fail(res, buf, "ERROR", "waiting to make request: %v", err)
+	}
+	defer release()
+	if timeout > 0 { // Covers reading the body as well, which is why it's not done in makeRequest
+		var cancel context.CancelFunc
+		req.CTX, cancel = context.WithTimeout(req.CTX, timeout)
+		defer cancel()
+	}
 
-	resp, err := makeRequest(client, req)
+	ses.Reporter.RequestSent(set, name, req)
+	resp, tracer, err := makeRequest(ses.Client, req)
+	if err != nil && timeout > 0 && errors.Is(req.CTX.Err(), context.DeadlineExceeded) {
+		return map[string][]string{}, fail(res, buf, "ERROR", "making request: timed out after %v", timeout)
+	}
 	if err != nil {
-		fmt.Fprintf(buf, "\n%s: making request: %v\n", pink("ERROR"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "ERROR", "making request: %v", err)
 	}
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
+	if err != nil && timeout > 0 && errors.Is(req.CTX.Err(), context.DeadlineExceeded) {
+		return map[string][]string{}, fail(res, buf, "ERROR", "reading response body: timed out after %v", timeout)
+	}
 	if err != nil {
-		fmt.Fprintf(buf, "\n%s: reading response body: %v\n", pink("ERROR"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "ERROR", "reading response body: %v", err)
 	}
+	timing := tracer.done()
+	res.Response = &Response{resp.StatusCode, resp.Header, string(body), timing}
+	ses.Reporter.ResponseReceived(set, name, *res.Response)
 
-	printResp(buf, resp, body, expected)
+	printResp(buf, resp, body, timing, expected)
+
+	if ses.contract != nil {
+		for _, violation := range ses.contract.validate(context.Background(), req, resp.StatusCode, resp.Header, body) {
+			if ses.contract.warn {
+				fmt.Fprintf(buf, "\n%s: validating against OpenAPI spec: %s\n", yellow("WARNING"), violation)
+				res.Warnings = append(res.Warnings, violation)
+				continue
+			}
+			res = fail(res, buf, "FAIL", "validating against OpenAPI spec: %s", violation)
+		}
+		if len(res.Failures) > 0 {
+			return map[string][]string{}, res
+		}
+	}
 
 	parsedBody, err = parseBody(body, resp.Header.Get("Content-Type"))
 	if err != nil {
-		fmt.Fprintf(buf, "\n%s: parsing response body: %v\n", pink("ERROR"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "ERROR", "parsing response body: %v", err)
 	}
 
 	if err := assertStatus(expected.Status, resp.StatusCode); err != nil {
-		fmt.Fprintf(buf, "\n%s: asserting status: %v\n", pink("FAIL"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "FAIL", "asserting status: %v", err)
 	}
 	if err := assertHeaders(expected.Headers, resp.Header); err != nil {
-		fmt.Fprintf(buf, "\n%s: asserting header: %v\n", pink("FAIL"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "FAIL", "asserting header: %v", err)
 	}
 	if err := assertBody(expected.Body, parsedBody); err != nil {
-		fmt.Fprintf(buf, "\n%s: asserting body: %v\n", pink("FAIL"), err)
-		return map[string][]string{}, testResult{buf, false}
+		return map[string][]string{}, fail(res, buf, "FAIL", "asserting body: %v", err)
+	}
+	violations, err := validateSchema(expected.Schema, body)
+	if err != nil {
+		return map[string][]string{}, fail(res, buf, "ERROR", "asserting schema: %v", err)
+	}
+	for _, violation := range violations {
+		res = fail(res, buf, "FAIL", "asserting schema: %s", violation)
+	}
+	if len(violations) > 0 {
+		return map[string][]string{}, res
 	}
+	if err := assertDuration(expected.MaxTTFB, timing.TTFB); err != nil {
+		return map[string][]string{}, fail(res, buf, "FAIL", "asserting time to first byte: %v", err)
+	}
+	if err := assertDuration(expected.MaxDuration, timing.Total); err != nil {
+		return map[string][]string{}, fail(res, buf, "FAIL", "asserting duration: %v", err)
+	}
+
+	res.Passed = true
+	return parsedBody, res
+}
+
+// fail logs a failure to buf and records the same message as a failure of res.
+func fail(res TestReport, buf *bytes.Buffer, label string, format string, args ...any) TestReport {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintf(buf, "\n%s: %s\n", pink(label), msg)
+	res.Passed = false
+	res.Failures = append(res.Failures, msg)
+	return res
+}
 
-	return parsedBody, testResult{buf, true}
+// tracer times the phases of an HTTP call. Its hooks may be called from other goroutines, even
+// after the call is done if a connection dialed for it ends up unused.
+type tracer struct {
+	mu                       sync.Mutex
+	start, dns, connect, tls time.Time // Start of each phase
+	timing                   Timing
 }
 
-func makeRequest(client *http.Client, reqSetup Request) (*http.Response, error) {
+func (t *tracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.begin(&t.dns) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.end(&t.dns, &t.timing.DNS) },
+		ConnectStart:         func(string, string) { t.begin(&t.connect) },
+		ConnectDone:          func(string, string, error) { t.end(&t.connect, &t.timing.Connect) },
+		TLSHandshakeStart:    func() { t.begin(&t.tls) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.end(&t.tls, &t.timing.TLS) },
+		GotFirstResponseByte: func() { t.end(&t.start, &t.timing.TTFB) },
+	}
+}
+
+func (t *tracer) begin(start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*start = time.Now()
+}
+
+func (t *tracer) end(start *time.Time, d *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*d = time.Since(*start)
+}
+
+// done returns the timing of the call, which is done once its response body has been read.
+func (t *tracer) done() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
+
+func makeRequest(client *http.Client, reqSetup Request) (*http.Response, *tracer, error) {
 	if reqSetup.CTX == nil {
 		reqSetup.CTX = context.Background()
 	}
 
-	req, err := http.NewRequestWithContext(reqSetup.CTX, reqSetup.Method, reqSetup.URL, io.NopCloser(strings.NewReader(reqSetup.Body)))
+	tracer := &tracer{}
+	ctx := httptrace.WithClientTrace(reqSetup.CTX, tracer.trace())
+	req, err := http.NewRequestWithContext(ctx, reqSetup.Method, reqSetup.URL, io.NopCloser(strings.NewReader(reqSetup.Body)))
 	if err != nil {
-		return nil, fmt.Errorf("setting up: %v", err)
+		return nil, nil, fmt.Errorf("setting up: %v", err)
 	}
 
 	for _, h := range reqSetup.Headers {
 		req.Header.Add(h.Key, h.Val)
 	}
 
+	tracer.start = time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing: %v", err)
+		return nil, nil, fmt.Errorf("executing: %v", err)
 	}
 
-	return resp, nil
+	return resp, tracer, nil
 }
 
 func printReq(buf *bytes.Buffer, req Request) {
@@ -89,8 +194,8 @@ func printReq(buf *bytes.Buffer, req Request) {
 		fmt.Fprint(buf, grey("-> ")+format([]byte(req.Body), req.Content))
 	}
 }
-func printResp(buf *bytes.Buffer, resp *http.Response, body []byte, expected Expect) {
-	fmt.Fprintln(buf, grey("<-"), resp.StatusCode)
+func printResp(buf *bytes.Buffer, resp *http.Response, body []byte, timing Timing, expected Expect) {
+	fmt.Fprintln(buf, grey("<-"), resp.StatusCode, grey("("+timing.String()+")"))
 	for k, v := range resp.Header {
 		if slices.ContainsFunc(expected.Headers, func(header header) bool {
 			return header.Key == k
@@ -112,6 +217,13 @@ func assertStatus(expected int, actual int) error {
 	return nil
 }
 
+func assertDuration(limit time.Duration, actual time.Duration) error {
+	if limit != 0 && actual > limit {
+		return fmt.Errorf("too slow, got: %v want at most: %v", actual.Round(time.Millisecond), limit)
+	}
+	return nil
+}
+
 func assertHeaders(expected []header, actual http.Header) error {
 	for _, h := range expected {
 		res, ok := actual[h.Key]
@@ -0,0 +1,151 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Schema is a JSON Schema that a response body must be valid against. The schema is given either
+// inline as JSON or as the path of a file, in the file system FS if set. Draft 2020-12 is assumed
+// unless the schema states otherwise with $schema. References to other files are resolved relative
+// to File. Each schema is compiled once and reused by every test using it.
+//
+//	e2e.Schema{JSON: `{"type": "object", "required": ["id"]}`}
+//	e2e.Schema{File: "schemas/user.json"}
+//	e2e.Schema{FS: schemas, File: "user.json"} // schemas being an embed.FS
+type Schema struct {
+	// JSON is an inline schema. It takes precedence over File.
+	JSON string
+	// File is the path of a schema file, relative to the directory the tests are run from unless FS
+	// is set.
+	File string
+	// FS is a file system, eg. an embed.FS, containing File.
+	FS fs.FS
+}
+
+// empty reports whether no schema is set.
+func (s Schema) empty() bool {
+	return s.JSON == "" && s.File == ""
+}
+
+// compiledSchemas holds the compiled schema, or the error compiling it, of every Schema used so
+// far, so that each one is only read and compiled once however many times it is validated against.
+var compiledSchemas sync.Map
+
+type compiledSchema struct {
+	schema *jsonschema.Schema
+	err    error
+}
+
+// compiled returns the schema compiled, compiling it only the first time.
+func (s Schema) compiled() (*jsonschema.Schema, error) {
+	if s.FS != nil && !reflect.ValueOf(s.FS).Comparable() { // Can't be a key, eg. an fstest.MapFS
+		return s.compile()
+	}
+	if c, ok := compiledSchemas.Load(s); ok {
+		return c.(compiledSchema).schema, c.(compiledSchema).err
+	}
+	schema, err := s.compile()
+	compiledSchemas.Store(s, compiledSchema{schema, err})
+	return schema, err
+}
+
+func (s Schema) compile() (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	switch {
+	case s.JSON != "":
+		doc, err := jsonschema.UnmarshalJSON(strings.NewReader(s.JSON))
+		if err != nil {
+			return nil, fmt.Errorf("parsing inline schema: %v", err)
+		}
+		if err := c.AddResource("inline.json", doc); err != nil {
+			return nil, err
+		}
+		return c.Compile("inline.json")
+	case s.FS != nil:
+		c.UseLoader(fsLoader{s.FS})
+		return c.Compile("fs:///" + strings.TrimPrefix(s.File, "/"))
+	default:
+		return c.Compile(s.File)
+	}
+}
+
+// fsLoader loads schemas referenced by fs:///path URLs from a file system.
+type fsLoader struct {
+	fs fs.FS
+}
+
+func (l fsLoader) Load(rawURL string) (any, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "fs" {
+		return nil, fmt.Errorf("can't load %s, only files in the schema's file system", rawURL)
+	}
+	content, err := fs.ReadFile(l.fs, strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(content))
+}
+
+// validateSchema validates body against expected, returning one message per violation, each
+// prefixed with the JSON pointer of the violating value. The error is non-nil if the validation
+// couldn't be made at all.
+func validateSchema(expected Schema, body []byte) ([]string, error) {
+	if expected.empty() {
+		return nil, nil
+	}
+	schema, err := expected.compiled()
+	if err != nil {
+		return nil, fmt.Errorf("compiling schema: %v", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parsing body as JSON: %v", err)
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	violations := []string{}
+	printer := message.NewPrinter(language.English)
+	var collect func(verr *jsonschema.ValidationError)
+	collect = func(verr *jsonschema.ValidationError) {
+		for _, cause := range verr.Causes {
+			collect(cause)
+		}
+		if len(verr.Causes) == 0 { // Only leaves describe actual violations
+			violations = append(violations, fmt.Sprintf("%q: %s", pointer(verr.InstanceLocation), verr.ErrorKind.LocalizedString(printer)))
+		}
+	}
+	collect(verr)
+	slices.Sort(violations)
+	return violations, nil
+}
+
+// pointer returns the JSON pointer of the value at path.
+func pointer(path []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	ptr := ""
+	for _, token := range path {
+		ptr += "/" + escaper.Replace(token)
+	}
+	return ptr
+}
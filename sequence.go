@@ -3,9 +3,11 @@ package e2e
 import (
 	"bytes"
 	"fmt"
-	"net/http"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 var variable *regexp.Regexp = regexp.MustCompile(`\$\w+`)
@@ -14,33 +16,137 @@ type (
 	Sequence struct {
 		// The name of the sequence. Used for test logs.
 		Name string
+		// Timeout is the default timeout of the HTTP calls of all tests in the sequence. It overrides
+		// the Timeout of the Runner.
+		Timeout time.Duration
+		// Tags label the sequence. Since steps can't run on their own a sequence is treated as
+		// having the tags of all its steps as well.
+		Tags Tags
+		// BeforeAll contains actions performed before the first step. Variables set by them are
+		// available to all steps. If an action fails no steps are run.
+		BeforeAll Before
+		// AfterAll contains actions performed after the last step, even if a step or BeforeAll
+		// failed, with access to all variables set or captured before that.
+		AfterAll After
+		// Table runs the whole sequence once per row, each row's values being available as
+		// variables to all steps. Steps of each run are named "rowName/step 1", etc.
+		Table Table
 		// The tests/steps contained within this Sequence.
 		Steps Steps
+		// Finally contains steps that are always run after Steps, even if a step failed, eg. to
+		// delete resources created by earlier steps. They have access to all variables captured
+		// before the failure. Finally steps are all run even if one of them fails, and they are
+		// named "finally 1", "finally 2", etc. to tell their failures apart from those of Steps.
+		Finally Steps
 	}
 	// Steps is an ordered slice. In sequences tests/steps are unnamed and simply displayed as
 	// "step 1", "step 2", etc. in logs.
-	Steps []test
+	Steps []Test
 )
 
-func (s Sequence) run(client *http.Client) result {
+// Run runs the steps of the sequence in order, sharing captured variables between them. Steps
+// after a failing step are skipped while Finally steps are always run. A sequence with a Table is
+// run once per row, one row after the other.
+func (s Sequence) Run(ses Session) SetReport {
+	ses.Reporter.SetStart(s.Name)
+	if s.Timeout != 0 {
+		ses.Timeout = s.Timeout
+	}
+	start := time.Now()
 	buf := &bytes.Buffer{}
-	allPassed := true
-	data := make(map[string]string)
 
 	fmt.Fprintln(buf, yellow("\n---------------------------------"))
 	fmt.Fprintln(buf, yellow(" TEST SEQUENCE - ", strings.ToUpper(s.Name)))
 	fmt.Fprintln(buf, yellow("---------------------------------"))
 
-	numRun := 0
-	for i, step := range s.Steps {
-		fmt.Fprintln(buf, "Step", i+1)
-		numRun = i + 1
-		if result := step.run(client, buf, data); !result.passed {
-			allPassed = false
-			break
+	var steps []TestReport
+	allPassed := true
+	if s.Table.empty() {
+		steps, allPassed = s.run(ses, "", make(map[string]string), buf)
+	} else if rows, names, err := s.Table.rows(); err != nil {
+		result := ses.failure(s.Name, "Table", &bytes.Buffer{}, "%v", err)
+		buf.WriteString(result.Log)
+		steps, allPassed = []TestReport{result}, false
+	} else {
+		for i, row := range rows {
+			fmt.Fprintln(buf, yellow("Row: ", names[i]))
+			rowSteps, passed := s.run(ses, names[i]+"/", maps.Clone(row), buf)
+			steps = append(steps, rowSteps...)
+			allPassed = allPassed && passed
 		}
-		fmt.Fprintln(buf)
 	}
 	fmt.Fprintf(buf, "---------------------------------\nSEQUENCE RESULT: %s\n", resultText(allPassed))
-	return result{buf, allPassed, numRun}
+	return SetReport{s.Name, allPassed, time.Since(start), steps, buf.String()}
+}
+
+// run runs the sequence once with the variables of data, prefixing the names of all steps with
+// prefix.
+func (s Sequence) run(ses Session, prefix string, data map[string]string, buf *bytes.Buffer) ([]TestReport, bool) {
+	allPassed := true
+	steps := []TestReport{}
+	hooks := []TestReport{}
+
+	if result, ok := ses.hook(s.Name, prefix+"BeforeAll", s.BeforeAll, data, buf); !ok {
+		allPassed = false
+		hooks = append(hooks, result)
+	}
+	for i, step := range s.Steps {
+		name := fmt.Sprintf("%sstep %d", prefix, i+1)
+		if !allPassed { // Steps after a failing step or BeforeAll are never run
+			steps = append(steps, TestReport{Name: name, Skipped: true})
+			continue
+		}
+		result := s.step(ses, name, fmt.Sprint("Step ", i+1), step, data, buf)
+		allPassed = result.Passed
+		steps = append(steps, result)
+	}
+	finallyPassed := true
+	for i, step := range s.Finally {
+		result := s.step(ses, fmt.Sprintf("%sfinally %d", prefix, i+1), fmt.Sprint("Finally ", i+1), step, data, buf)
+		finallyPassed = finallyPassed && result.Passed
+		steps = append(steps, result)
+	}
+	if len(s.Finally) > 0 {
+		fmt.Fprintf(buf, "FINALLY RESULT: %s\n", resultText(finallyPassed))
+		allPassed = allPassed && finallyPassed
+	}
+	if result, ok := ses.hook(s.Name, prefix+"AfterAll", s.AfterAll, data, buf); !ok {
+		allPassed = false
+		hooks = append(hooks, result)
+	}
+	return slices.Concat(steps, hooks), allPassed
+}
+
+// step runs a single step named name, logging it under header.
+func (s Sequence) step(ses Session, name, header string, step Test, data map[string]string, buf *bytes.Buffer) TestReport {
+	start := time.Now()
+	stepBuf := &bytes.Buffer{}
+	ses.Reporter.TestStart(s.Name, name)
+	fmt.Fprintln(stepBuf, header)
+	var result TestReport
+	if !step.Table.empty() { // Running a step once per row would leave later steps with one row only
+		result = fail(TestReport{Request: step.Request}, stepBuf, "ERROR", "Table isn't supported on steps, use the Table of the Sequence to run it once per row")
+		ses.Reporter.AssertionFailed(s.Name, name, result.Failures[0])
+	} else {
+		result = step.run(ses, s.Name, name, stepBuf, data)
+	}
+	if result.Passed {
+		fmt.Fprintln(stepBuf)
+	}
+	result.Name = name
+	result.Duration = time.Since(start)
+	result.Log = stepBuf.String()
+	ses.Reporter.TestEnd(s.Name, result)
+	buf.WriteString(result.Log)
+	return result
+}
+
+// Select returns the whole sequence if selected by its name and the tags of the sequence and all its
+// steps, since steps can't run on their own.
+func (s Sequence) Select(selected func(path string, tags Tags) bool) (Set, bool) {
+	tags := slices.Clone(s.Tags)
+	for _, step := range slices.Concat(s.Steps, s.Finally) {
+		tags = append(tags, step.Tags...)
+	}
+	return s, selected(s.Name, tags)
 }
@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Set is a collection of tests run by the [Runner], like [Suite] and [Sequence]. Implementing Set
+// makes it possible to contribute new kinds of sets, and e2r runs exported variables of any type
+// implementing it.
+type Set interface {
+	// Run runs the tests of the set and returns the outcome. Sets are run concurrently with each
+	// other. Run must report SetStart before running any tests, and TestStart and TestEnd around
+	// each test unless using [Session.RunTest], which reports all events of a test. SetEnd is
+	// reported by the Runner once Run returns.
+	Run(ses Session) SetReport
+}
+
+// Selector is optionally implemented by a [Set] to support selecting tests by name and tags, like
+// [Runner.Filter], [Runner.Tags] and [Runner.SkipTags] do. Sets not implementing Selector are
+// always run.
+type Selector interface {
+	// Select returns the set with only the tests for which selected returns true, and false if no
+	// tests are selected. path is the name a test is matched by, eg. "SetName/testName".
+	Select(selected func(path string, tags Tags) bool) (Set, bool)
+}
+
+// Session holds what is shared by all sets during a run. It is created by the Runner and passed to
+// [Set.Run].
+type Session struct {
+	// Client is the HTTP client shared by all sets. It doesn't follow redirects. Requests made
+	// directly with Client aren't subject to the Parallel and RateLimits of the Runner, which
+	// [Session.RunTest] respects.
+	Client *http.Client
+	// Reporter receives the events of the run.
+	Reporter Reporter
+	// Timeout is the default timeout of requests. A set may override it before running its tests.
+	Timeout  time.Duration
+	limiter  *limiter
+	contract *contract
+}
+
+// RunTest runs t as the test name of set and reports its events to the Reporter of the session.
+// data holds the variables available to the test and receives the values it captures. The
+// returned report is complete, including the name, duration and log of the test.
+func (ses Session) RunTest(set, name string, t Test, data map[string]string) TestReport {
+	start := time.Now()
+	buf := &bytes.Buffer{}
+	ses.Reporter.TestStart(set, name)
+	fmt.Fprintln(buf, "--------", name, "--------")
+	result := t.run(ses, set, name, buf, data)
+	if result.Passed {
+		fmt.Fprintln(buf, "\nSuccess!")
+	}
+	result.Name = name
+	result.Duration = time.Since(start)
+	result.Log = buf.String()
+	ses.Reporter.TestEnd(set, result)
+	return result
+}
+
+// hook performs the set level actions of set, eg. its BeforeAll, logging them to buf. If an action
+// fails hook returns false along with a failed report named name.
+func (ses Session) hook(set, name string, actions []func(data map[string]string) (string, error), data map[string]string, buf *bytes.Buffer) (TestReport, bool) {
+	hookBuf := &bytes.Buffer{}
+	err := perform(hookBuf, name, actions, data)
+	if err == nil {
+		buf.Write(hookBuf.Bytes())
+		return TestReport{}, true
+	}
+	result := ses.failure(set, name, hookBuf, "performing %s action: %v", name, err)
+	buf.WriteString(result.Log)
+	return result, false
+}
+
+// failure reports an error keeping name of set from running, eg. a failing BeforeAll, as a failed
+// test so that it shows up next to the tests of the set. log holds what was logged before the error.
+func (ses Session) failure(set, name string, log *bytes.Buffer, format string, args ...any) TestReport {
+	ses.Reporter.TestStart(set, name)
+	result := fail(TestReport{Name: name}, log, "ERROR", format, args...)
+	result.Log = log.String()
+	ses.Reporter.AssertionFailed(set, name, result.Failures[0])
+	ses.Reporter.TestEnd(set, result)
+	return result
+}
@@ -3,11 +3,16 @@
 package e2e
 
 import (
-	"bytes"
 	"fmt"
 	"net/http"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+
+	"golang.org/x/term"
 )
 
 // The runner is the core component that run tests. It is mostly called by the [e2r] application
@@ -15,44 +20,142 @@ import (
 type Runner struct {
 	BeforeRun func() any // Sets up environment before running any tests.
 	AfterRun  func(any)  // Tears down environment after running all tests.
+	// CI disables all prompts and the progress bar, making the run suitable for pipelines. It is
+	// enabled automatically when stdin is not a terminal.
+	CI bool
+	// Logs decides which test logs are printed after the run. If left empty the user is asked
+	// when running interactively while only logs of failed sets are printed in CI mode.
+	Logs Logs
+	// JUnit is the path of a file to which a JUnit XML report is written after the run. No report
+	// is written if left empty.
+	JUnit string
+	// HTML is the path of a file to which a self-contained HTML report is written after the run.
+	// No report is written if left empty.
+	HTML string
+	// JSON is the path of a file to which the [Report] is written as JSON after the run. No report
+	// is written if left empty.
+	JSON string
+	// NDJSON is the path of a file to which events are streamed as newline delimited JSON during
+	// the run. If set to "-" events are streamed to stdout instead of the default terminal output.
+	NDJSON string
+	// Filter is a regular expression selecting which tests to run, like the -run flag of go test.
+	// Tests in Suites are matched as "SuiteName/testName" while Sequences are matched by name
+	// only, since their steps can't run on their own. All tests are run if left empty.
+	Filter string
+	// Tags selects tests having at least one of the tags. All tests are run if left empty.
+	Tags []string
+	// SkipTags excludes tests having any of the tags.
+	SkipTags []string
+	// Timeout is the default timeout of the HTTP call of every test. It can be overridden by
+	// Suites, Sequences and individual requests. No timeout is used if left unset.
+	Timeout time.Duration
+	// Parallel caps the number of requests in flight at any time. Requests are unbounded if left
+	// unset.
+	Parallel int
+	// RateLimits limits the number of requests per second by host, eg. "localhost:8080" or
+	// "api.mysite.com". The host "*" applies to all hosts not listed, each host being limited
+	// separately.
+	RateLimits map[string]float64
+	// OpenAPI validates every request and response against an OpenAPI 3 spec.
+	OpenAPI OpenAPI
+	// Load turns the run into a load test, running the sets repeatedly with Load.Users virtual
+	// users for Load.Duration. A normal run is made if Load.Users is unset. See [Runner.RunLoad].
+	Load Load
+	// Reporters receive events as the run progresses and render its output. If left empty [Run]
+	// uses a [Terminal] reporter configured with CI and Logs.
+	Reporters []Reporter
 }
 
-type set interface {
-	run(*http.Client) result
+// Logs is a policy deciding which test logs are printed after a run.
+type Logs string
+
+const (
+	LogsAll    Logs = "all"    // Print logs of all sets.
+	LogsFailed Logs = "failed" // Print logs of failed sets only.
+	LogsNone   Logs = "none"   // Print no logs, only the total result.
+)
+
+// interactive is false when running in CI mode. It tells prompting before-actions not to wait for
+// user input.
+var interactive = true
+
+// Run starts the engine, runs suites and sequences concurrently or sequentially depending on their
+// type. It handles the whole run from start to finish including printing output. Run returns true
+// if all sets passed. If Load is set Run makes a load test instead, printing its statistics and
+// returning true if no test failed.
+func (r Runner) Run(sets ...Set) bool {
+	if r.Load.Users > 0 {
+		fmt.Printf("Load testing with %d users for %v\n", r.Load.Users, r.Load.Duration)
+		report := r.RunLoad(sets...)
+		report.Print(os.Stdout)
+		return report.Passed
+	}
+	r.ensureMode()
+	if len(r.Reporters) == 0 && r.NDJSON != "-" {
+		r.Reporters = []Reporter{&Terminal{CI: r.CI, Logs: r.Logs}}
+	}
+	return r.run(sets).Passed
 }
 
-type result struct {
-	buf    *bytes.Buffer
-	passed bool
-	numRun int
+// RunReport runs suites and sequences just like [Runner.Run] but without the default terminal
+// output. Instead the outcome is returned as a [Report] for further processing.
+func (r Runner) RunReport(sets ...Set) Report {
+	r.ensureMode()
+	return r.run(sets)
 }
 
-// Run starts the engine, runs suites and sequences concurrently or sequentially depending on their
-// type. It handles the whole run from start to finish including printing output.
-func (r Runner) Run(sets ...set) {
+// run runs all sets concurrently, reporting events to all reporters of r.
+func (r Runner) run(sets []Set) Report {
+	sets, err := r.selectSets(sets)
+	if err != nil {
+		fmt.Printf("%s: %v\n", pink("ERROR"), err)
+		return Report{Passed: false}
+	}
+
 	r.ensureHooks()
 	before := r.BeforeRun()
 	defer r.AfterRun(before)
+	start := time.Now()
 
-	ch := make(chan result)
+	rep := reporters(r.Reporters)
+	if r.JUnit != "" {
+		rep = append(rep, junitFile{path: r.JUnit})
+	}
+	if r.HTML != "" {
+		rep = append(rep, htmlFile{path: r.HTML})
+	}
+	if r.JSON != "" {
+		rep = append(rep, jsonFile{path: r.JSON})
+	}
+	switch r.NDJSON {
+	case "":
+	case "-":
+		rep = append(rep, &NDJSON{W: os.Stdout})
+	default:
+		file, err := os.Create(r.NDJSON)
+		if err != nil {
+			fmt.Printf("\n%s: streaming NDJSON events: creating file: %v\n", pink("ERROR"), err)
+			break
+		}
+		defer file.Close()
+		rep = append(rep, &NDJSON{W: file})
+	}
+	ch := make(chan SetReport)
 	wg := sync.WaitGroup{}
-	client := &http.Client{
-		// Don't follow redirects
-		CheckRedirect: func(*http.Request, []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+	s, err := r.session(rep)
+	if err != nil {
+		fmt.Printf("%s: %v\n", pink("ERROR"), err)
+		return Report{Passed: false}
 	}
-	numRun := 0
-	numPassed := 0
-	results := []result{}
+	report := Report{Passed: true}
 
-	drawProgressBar(results, len(sets))
-	for _, s := range sets {
+	rep.RunStart(len(sets))
+	for _, st := range sets {
 		wg.Add(1)
-		go func(set set) {
+		go func(set Set) {
 			defer wg.Done()
-			ch <- set.run(client)
-		}(s)
+			ch <- set.Run(s)
+		}(st)
 	}
 
 	go func() {
@@ -61,37 +164,60 @@ func (r Runner) Run(sets ...set) {
 	}()
 
 	for result := range ch {
-		if result.passed {
-			numPassed++
+		if !result.Passed {
+			report.Passed = false
+		}
+		report.Sets = append(report.Sets, result)
+		rep.SetEnd(result)
+	}
+	// Sets finish in any order, so they're sorted for reports to be the same from run to run
+	slices.SortStableFunc(report.Sets, func(a, b SetReport) int { return strings.Compare(a.Name, b.Name) })
+
+	report.Duration = time.Since(start)
+	rep.RunEnd(report)
+	return report
+}
+
+// selectSets returns the sets, and tests of sets, selected by Filter, Tags and SkipTags.
+func (r Runner) selectSets(sets []Set) ([]Set, error) {
+	f := filter{tags: r.Tags, skipTags: r.SkipTags}
+	if r.Filter != "" {
+		pattern, err := regexp.Compile(r.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %v", err)
 		}
-		numRun += result.numRun
-		results = append(results, result)
-		drawProgressBar(results, len(sets))
-	}
-
-	allPassed := numPassed == len(sets)
-	numFailed := len(sets) - numPassed
-
-	fmt.Printf(`
----------------------------------
-TOTAL RESULT: %s
-Num sets run: %5d (%d tests)
-Failed sets: %6d
-`, resultText(allPassed), len(sets), numRun, numFailed)
-
-	input := confirm(`Do you want to see full test logs (vs only failed)? [y/N]: `)
-	full := strings.ToLower(strings.Trim(input, "\n")) == "y"
-
-	for _, result := range results {
-		switch full {
-		case true:
-			fmt.Print(result.buf.String())
-		case false:
-			if !result.passed {
-				fmt.Print(result.buf.String())
+		f.pattern = pattern
+	}
+	selected := []Set{}
+	for _, st := range sets {
+		if sel, ok := st.(Selector); ok {
+			if st, ok = sel.Select(f.matches); !ok {
+				continue
 			}
 		}
+		selected = append(selected, st)
 	}
+	return selected, nil
+}
+
+// session returns a new session reporting to rep.
+func (r Runner) session(rep Reporter) (Session, error) {
+	contract, err := loadContract(r.OpenAPI)
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{
+		Client: &http.Client{
+			// Don't follow redirects
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Reporter: rep,
+		Timeout:  r.Timeout,
+		limiter:  newLimiter(r.Parallel, r.RateLimits),
+		contract: contract,
+	}, nil
 }
 
 func (r *Runner) ensureHooks() {
@@ -102,3 +228,10 @@ func (r *Runner) ensureHooks() {
 		r.AfterRun = func(any) {}
 	}
 }
+
+func (r *Runner) ensureMode() {
+	if !r.CI && (r.NDJSON == "-" || !term.IsTerminal(int(os.Stdin.Fd()))) { // Prompts would garble the stream
+		r.CI = true
+	}
+	interactive = !r.CI
+}
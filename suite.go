@@ -3,45 +3,98 @@ package e2e
 import (
 	"bytes"
 	"fmt"
-	"net/http"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
 type (
 	Suite struct {
 		// The name of the suite. Used for test logs.
-		Name  string
+		Name string
+		// Timeout is the default timeout of the HTTP calls of all tests in the suite. It overrides
+		// the Timeout of the Runner.
+		Timeout time.Duration
+		// Tags label the suite and are inherited by all its tests.
+		Tags Tags
+		// BeforeAll contains actions performed once before any of the tests. Variables set by them,
+		// eg. ids of created fixtures, are available to all tests of the suite. If an action fails
+		// no tests are run.
+		BeforeAll Before
+		// BeforeEach contains actions performed before each test, ahead of the test's own Before.
+		// Each test has its own copy of the variables set by BeforeAll, so variables set by
+		// BeforeEach are never shared between tests.
+		BeforeEach Before
+		// AfterEach contains actions performed after each test whether it passed or not, with access
+		// to the variables captured by the test. A failing action fails the test.
+		AfterEach After
+		// AfterAll contains actions performed once after all tests, even if BeforeAll failed, with
+		// access to the variables set by BeforeAll.
+		AfterAll After
 		// The tests contained within this Suite.
 		Tests Tests
 	}
 	// Tests is an unordered map. Each key is a test name and each value is a Test. The test names
 	// are used for test logs.
-	Tests map[string]test
+	Tests map[string]Test
 )
 
-func (s Suite) run(client *http.Client) result {
+// Run runs all tests of the suite concurrently, tests with a Table running once per row.
+func (s Suite) Run(ses Session) SetReport {
+	ses.Reporter.SetStart(s.Name)
+	if s.Timeout != 0 {
+		ses.Timeout = s.Timeout
+	}
+	start := time.Now()
 	buf := &bytes.Buffer{}
-	ch := make(chan testResult)
+	ch := make(chan TestReport)
 	wg := sync.WaitGroup{}
-	numPassed := 0
+	tests := []TestReport{}
+	hooks := []TestReport{}
+	data := map[string]string{}
 
 	fmt.Fprintln(buf, yellow("\n---------------------------------"))
 	fmt.Fprintln(buf, yellow(" TEST SUITE - ", strings.ToUpper(s.Name)))
 	fmt.Fprintln(buf, yellow("---------------------------------"))
 
-	for name, t := range s.Tests {
+	result, ok := ses.hook(s.Name, "BeforeAll", s.BeforeAll, data, buf)
+	if !ok {
+		hooks = append(hooks, result)
+	}
+	runTest := func(name string, test Test, data map[string]string) {
 		wg.Add(1)
-		go func(name string, test test) {
+		go func() {
 			defer wg.Done()
-			buf := &bytes.Buffer{}
-			fmt.Fprintln(buf, "--------", name, "--------")
-			result := test.run(client, buf, map[string]string{})
-			if result.passed {
-				fmt.Fprintln(buf, "\nSuccess!")
-			}
-			ch <- result
-		}(name, t)
+			ch <- ses.RunTest(s.Name, name, test, data)
+		}()
+	}
+	for name, t := range s.Tests {
+		if !ok { // Tests are never run without their fixtures
+			tests = append(tests, TestReport{Name: name, Skipped: true})
+			continue
+		}
+		t.Before = slices.Concat(s.BeforeEach, t.Before)
+		t.after = s.AfterEach
+		if t.Table.empty() {
+			runTest(name, t, maps.Clone(data))
+			continue
+		}
+		rows, names, err := t.Table.rows()
+		if err != nil {
+			log := &bytes.Buffer{}
+			fmt.Fprintln(log, "--------", name, "--------")
+			result := ses.failure(s.Name, name, log, "%v", err)
+			buf.WriteString(result.Log)
+			tests = append(tests, result)
+			continue
+		}
+		for i, row := range rows {
+			rowData := maps.Clone(data)
+			maps.Copy(rowData, row)
+			runTest(name+"/"+names[i], t, rowData)
+		}
 	}
 
 	go func() {
@@ -50,19 +103,43 @@ func (s Suite) run(client *http.Client) result {
 	}()
 
 	for result := range ch {
-		if result.passed {
+		buf.WriteString(result.Log)
+		tests = append(tests, result)
+	}
+	slices.SortFunc(tests, func(a, b TestReport) int { return strings.Compare(a.Name, b.Name) })
+
+	if result, ok := ses.hook(s.Name, "AfterAll", s.AfterAll, data, buf); !ok {
+		hooks = append(hooks, result)
+	}
+
+	numPassed, numFailed := 0, 0
+	for _, result := range tests {
+		switch {
+		case result.Passed:
 			numPassed++
+		case !result.Skipped:
+			numFailed++
 		}
-		buf.Write(result.buf.Bytes())
 	}
-
-	allPassed := numPassed == len(s.Tests)
-	numFailed := len(s.Tests) - numPassed
+	allPassed := numFailed == 0 && len(hooks) == 0
 
 	fmt.Fprintf(buf, `---------------------------------
 SUITE RESULT: %s
 Success: %d
 Fail: %d
 `, resultText(allPassed), numPassed, numFailed)
-	return result{buf, allPassed, len(s.Tests)}
+	return SetReport{s.Name, allPassed, time.Since(start), slices.Concat(tests, hooks), buf.String()}
+}
+
+// Select returns the suite with only the tests selected by name, matched as "SuiteName/testName",
+// and tags.
+func (s Suite) Select(selected func(path string, tags Tags) bool) (Set, bool) {
+	tests := Tests{}
+	for name, t := range s.Tests {
+		if selected(s.Name+"/"+name, slices.Concat(s.Tags, t.Tags)) {
+			tests[name] = t
+		}
+	}
+	s.Tests = tests
+	return s, len(tests) > 0
 }
@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type (
+	// Table contains rows of parameters for running a test or a sequence once per row. The values
+	// of each row are available as variables using the $-prefix, just like captured values.
+	Table struct {
+		// Name names the run of each row and may reference the values of the row, eg. "user $id".
+		// Runs are named "row 1", "row 2", etc. if left empty.
+		Name string
+		// Rows contains rows declared inline. Each row maps variable names to values.
+		Rows []Row
+		// File is the path of a CSV or JSON file containing rows, relative to the directory the
+		// tests are run from. A CSV file starts with a header naming the variables of each column,
+		// while a JSON file contains an array of objects. Rows in File follow inline Rows.
+		File string
+	}
+	// Row maps variable names to values.
+	Row map[string]string
+)
+
+// empty reports whether t has no rows, meaning the test or sequence is run once as usual.
+func (t Table) empty() bool {
+	return len(t.Rows) == 0 && t.File == ""
+}
+
+// rows returns all rows of t along with the name of each.
+func (t Table) rows() ([]Row, []string, error) {
+	rows := t.Rows
+	if t.File != "" {
+		loaded, err := loadRows(t.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading table: %v", err)
+		}
+		rows = slices.Concat(rows, loaded)
+	}
+
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i] = fmt.Sprintf("row %d", i+1)
+		if t.Name != "" {
+			names[i] = variable.ReplaceAllStringFunc(t.Name, func(s string) string {
+				return row[strings.TrimPrefix(s, "$")]
+			})
+		}
+	}
+	return rows, names, nil
+}
+
+func loadRows(path string) ([]Row, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", path, err)
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		rows := make([]Row, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := Row{}
+			for i, key := range records[0] {
+				row[key] = record[i]
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	case ".json":
+		var objects []map[string]any
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber() // Keeps numbers as written
+		if err := decoder.Decode(&objects); err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", path, err)
+		}
+		rows := make([]Row, 0, len(objects))
+		for _, object := range objects {
+			row := Row{}
+			for key, val := range object {
+				switch val := val.(type) {
+				case nil:
+					row[key] = ""
+				case string:
+					row[key] = val
+				case map[string]any, []any:
+					encoded, _ := json.Marshal(val)
+					row[key] = string(encoded)
+				default:
+					row[key] = fmt.Sprint(val)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, want .csv or .json", filepath.Ext(path))
+	}
+}
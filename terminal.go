@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Terminal is the default [Reporter]. It draws a progress bar while running, prints a summary
+// once done and then prints test logs according to its log policy.
+type Terminal struct {
+	NopReporter
+	// CI disables the progress bar and the log prompt.
+	CI bool
+	// Logs decides which test logs are printed after the run. If left empty the user is asked.
+	Logs Logs
+
+	mu    sync.Mutex
+	total int
+	done  []SetReport
+}
+
+func (t *Terminal) RunStart(numSets int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = numSets
+	if !t.CI {
+		drawProgressBar(t.done, t.total)
+	}
+}
+
+func (t *Terminal) SetEnd(set SetReport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done = append(t.done, set)
+	if !t.CI {
+		drawProgressBar(t.done, t.total)
+	}
+}
+
+func (t *Terminal) RunEnd(report Report) {
+	numRun := 0
+	numFailed := 0
+	for _, set := range report.Sets {
+		numRun += set.NumRun()
+		if !set.Passed {
+			numFailed++
+		}
+	}
+
+	fmt.Printf(`
+---------------------------------
+TOTAL RESULT: %s
+Num sets run: %5d (%d tests)
+Failed sets: %6d
+`, resultText(report.Passed), len(report.Sets), numRun, numFailed)
+	printSlowest(report, 10)
+
+	logs := t.Logs
+	if logs == "" && t.CI {
+		logs = LogsFailed
+	}
+	if logs == "" {
+		input := confirm(`Do you want to see full test logs (vs only failed)? [y/N]: `)
+		logs = LogsFailed
+		if strings.ToLower(strings.Trim(input, "\n")) == "y" {
+			logs = LogsAll
+		}
+	}
+
+	for _, set := range report.Sets {
+		switch logs {
+		case LogsAll:
+			fmt.Print(set.Log)
+		case LogsFailed:
+			if !set.Passed {
+				fmt.Print(set.Log)
+			}
+		}
+	}
+}
+
+// printSlowest prints the n tests of the run with the slowest HTTP calls. Tests without a response,
+// eg. failed hooks, are left out.
+func printSlowest(report Report, n int) {
+	type timed struct {
+		path     string
+		duration time.Duration
+	}
+	tests := []timed{}
+	for _, set := range report.Sets {
+		for _, test := range set.Tests {
+			if test.Response != nil {
+				tests = append(tests, timed{set.Name + "/" + test.Name, test.Response.Timing.Total})
+			}
+		}
+	}
+	if len(tests) == 0 {
+		return
+	}
+	slices.SortStableFunc(tests, func(a, b timed) int { return cmp.Compare(b.duration, a.duration) })
+
+	fmt.Println("---------------------------------\nSLOWEST TESTS")
+	for _, test := range tests[:min(n, len(tests))] {
+		fmt.Printf("%10v  %s\n", round(test.duration), test.path)
+	}
+}
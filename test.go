@@ -4,14 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"time"
 )
 
-type test struct {
+// Test is a single HTTP call along with expectations on its response. Tests are grouped into sets
+// such as [Suite] and [Sequence].
+type Test struct {
 	// Before contains a function that will be fun before this test. There are two helper functions
 	// that can be used to create Before functions, [Command] and [Input].
 	Before Before
@@ -22,16 +26,33 @@ type test struct {
 	// Capture contains strings matching fields in the HTTP response of which you'd like to capture
 	// the value.
 	Capture Captors
+	// Tags label the test in addition to the tags of the Suite or Sequence it belongs to.
+	Tags Tags
+	// Retry is a policy for retrying the test's HTTP call if it fails. Before-actions are only
+	// performed once.
+	Retry Retry
+	// Table runs the test of a Suite once per row, each row's values being available as
+	// variables. Each run is a test of its own, named "testName/rowName". Steps of a Sequence
+	// can't have a Table and fail if they do, see Sequence.Table instead.
+	Table Table
+	after After // Set by the Suite from its AfterEach
 }
 
 type (
 	// Before is a function type that can used to perform a pre test action.
 	Before []func(data map[string]string) (string, error)
+	// After is a function type that can be used to perform a post test action, eg. cleaning up
+	// resources created by tests. It has the same signature as Before and can use the same helpers.
+	After []func(data map[string]string) (string, error)
 	// Request contains all details necessary to perform a test's HTTP call.
 	Request struct {
 		// CTX is the context provided to the http.Client upon making the test's HTTP call.
 		// It defaults to context.Background().
-		CTX context.Context
+		CTX context.Context `json:"-"`
+		// Timeout bounds the time of making the HTTP call and reading its response. It defaults to
+		// the Timeout of the Suite or Sequence, which in turn defaults to that of the Runner. No
+		// timeout is used if none of them is set.
+		Timeout time.Duration
 		// The HTTP method of the request.
 		Method string
 		// The URL to which to make the HTTP call. It can either be hard coded as a string or looked
@@ -98,6 +119,16 @@ type (
 		//		"root.item@attr": "attrval",
 		//	}
 		Body Body
+		// Schema is a JSON Schema that the response body must be valid against. Every violation is
+		// reported along with the JSON pointer of the violating value.
+		Schema Schema
+		// MaxDuration is set if the HTTP call must not take longer than a certain time, measured
+		// from sending the request until the whole response body is read. Before-actions, time
+		// spent waiting on rate limits and earlier attempts don't count.
+		MaxDuration time.Duration
+		// MaxTTFB is set if the first byte of the response must arrive within a certain time of
+		// sending the request.
+		MaxTTFB time.Duration
 	}
 	Captors []string
 )
@@ -114,37 +145,69 @@ type (
 	Body map[string]any
 )
 
-func (t test) run(client *http.Client, buf *bytes.Buffer, data map[string]string) (result testResult) {
+func (t Test) run(ses Session, set, name string, buf *bytes.Buffer, data map[string]string) TestReport {
+	result := t.perform(ses, set, name, buf, data)
+	if err := perform(buf, "After test", t.after, data); err != nil {
+		result = fail(result, buf, "ERROR", "performing post test action: %v", err)
+		ses.Reporter.AssertionFailed(set, name, result.Failures[len(result.Failures)-1])
+	}
+	return result
+}
+
+func (t Test) perform(ses Session, set, name string, buf *bytes.Buffer, data map[string]string) TestReport {
 	if t.Request.Content != "" {
 		t.Request.Headers = append(t.Request.Headers, header{"Content-Type", t.Request.Content})
 	}
 
-	for _, action := range t.Before {
-		description, err := action(data)
-		fmt.Fprintf(buf, "Before test: %v\n", description)
-		if err != nil {
-			fmt.Fprintf(buf, "\n%s: performing pre test action: %v\n", pink("ERROR"), err)
-			return testResult{
-				buf:    buf,
-				passed: false,
-			}
-		}
+	if err := perform(buf, "Before test", t.Before, data); err != nil {
+		result := fail(TestReport{Request: t.Request}, buf, "ERROR", "performing pre test action: %v", err)
+		ses.Reporter.AssertionFailed(set, name, result.Failures[0])
+		return result
 	}
 
 	t.Request = inject(t.Request, data)
 
-	body, result := performTest(client, buf, t.Request, t.Expect)
-	if !result.passed {
+	body, result := performTest(ses, set, name, buf, t.Request, t.Expect)
+	result.Attempts = 1
+	for !result.Passed && result.Attempts < t.Retry.Attempts && t.Retry.retries(result) {
+		attempt := result.Attempts + 1
+		wait := t.Retry.backoff(attempt)
+		fmt.Fprintf(buf, "\nRetrying in %v, attempt %d of %d\n", wait, attempt, t.Retry.Attempts)
+		time.Sleep(wait)
+		body, result = performTest(ses, set, name, buf, t.Request, t.Expect)
+		result.Attempts = attempt
+	}
+	if !result.Passed {
+		for _, failure := range result.Failures {
+			ses.Reporter.AssertionFailed(set, name, failure)
+		}
 		return result
 	}
 
-	capture(body, data, t.Capture)
+	result.Captured = capture(body, data, t.Capture)
 
 	return result
 }
 
+// perform performs actions in order, logging their descriptions to buf with label. It stops at the
+// first action failing.
+func perform(buf *bytes.Buffer, label string, actions []func(data map[string]string) (string, error), data map[string]string) error {
+	for _, action := range actions {
+		description, err := action(data)
+		fmt.Fprintf(buf, "%s: %v\n", label, description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func Input(text string, mapTo string) func(data map[string]string) (string, error) {
 	return func(data map[string]string) (string, error) {
+		if !interactive {
+			return fmt.Sprintf("manual input %q", text), errors.New("input required but running in CI mode")
+		}
+
 		progressBarMutex.Lock()
 		defer progressBarMutex.Unlock()
 		reader := bufio.NewReader(os.Stdin)
@@ -172,13 +235,7 @@ func Input(text string, mapTo string) func(data map[string]string) (string, erro
 
 func Command(command string, args ...string) func(data map[string]string) (string, error) { // Can add mapTo as first argument to be able to capture output
 	return func(data map[string]string) (string, error) {
-		progressBarMutex.Lock()
-		defer progressBarMutex.Unlock()
-		reader := bufio.NewReader(os.Stdin)
-
-		moveDown(1) // To one line below progress bar
-		clearLine() // Clear line where prompt will be drawn
-
+		args := slices.Clone(args) // The action may be performed many times, eg. as a BeforeEach
 		for i, s := range args {
 			args[i] = variable.ReplaceAllStringFunc(s, func(str string) string {
 				str = strings.TrimPrefix(str, "$")
@@ -186,6 +243,20 @@ func Command(command string, args ...string) func(data map[string]string) (strin
 			})
 		}
 
+		if !interactive { // Nobody is there to read the output so just run the command
+			if _, err := exec.Command(command, args...).Output(); err != nil {
+				return fmt.Sprintf("command run %q", command), fmt.Errorf("executing command: %v", err)
+			}
+			return fmt.Sprintf("command run: %q", command), nil
+		}
+
+		progressBarMutex.Lock()
+		defer progressBarMutex.Unlock()
+		reader := bufio.NewReader(os.Stdin)
+
+		moveDown(1) // To one line below progress bar
+		clearLine() // Clear line where prompt will be drawn
+
 		cmd := exec.Command(command, args...)
 		out, err := cmd.Output()
 		if err != nil {
@@ -219,6 +290,7 @@ func inject(req Request, data map[string]string) Request {
 		return req
 	}
 
+	req.Headers = slices.Clone(req.Headers) // May be shared by concurrent tests, eg. rows of a Table
 	req.URL = variable.ReplaceAllStringFunc(req.URL, func(s string) string {
 		s = strings.TrimPrefix(s, "$")
 		return data[s]
@@ -238,10 +310,13 @@ func inject(req Request, data map[string]string) Request {
 	return req
 }
 
-func capture(body map[string][]string, data map[string]string, captors Captors) {
+func capture(body map[string][]string, data map[string]string, captors Captors) map[string]string {
+	captured := make(map[string]string)
 	for _, c := range captors {
 		if val, ok := body[c]; ok {
 			data[c] = fmt.Sprint(val[0]) ////TODO: Only loops through surface level fields.
+			captured[c] = data[c]
 		}
 	}
+	return captured
 }